@@ -1,6 +1,9 @@
 package ast
 
 import (
+    "fmt"
+    "math"
+    "strconv"
     "strings"
 
     "github.com/alecthomas/participle/v2/lexer"
@@ -21,24 +24,49 @@ type Block struct {
 type Statement struct {
     Pos lexer.Position
 
-    VarDecl    *VarDecl    `  @@`
-    ScanStmt   *ScanStmt   `| @@`
-    ScanlnStmt *ScanlnStmt `| @@`
-    CheckStmt  *CheckStmt  `| @@`
-    IfStmt     *IfStmt     `| @@`
-    ForStmt    *ForStmt    `| @@`
-    EOLStmt    *EOLStmt    `| @@`
-    EOFStmt    *EOFStmt    `| @@`
+    ConstDecl   *ConstDecl   `  @@`
+    VarDecl     *VarDecl     `| @@`
+    LetDecl     *LetDecl     `| @@`
+    ScanStmt    *ScanStmt    `| @@`
+    ScanlnStmt  *ScanlnStmt  `| @@`
+    CheckStmt   *CheckStmt   `| @@`
+    IfStmt      *IfStmt      `| @@`
+    ForStmt     *ForStmt     `| @@`
+    SubtaskStmt *SubtaskStmt `| @@`
+    EOLStmt     *EOLStmt     `| @@`
+    EOFStmt     *EOFStmt     `| @@`
+}
+
+type ConstDecl struct {
+    Pos lexer.Position
+
+    ConstSpec ConstSpec `"const" @@`
 }
 
 type VarDecl struct {
     VarSpec VarSpec `"var" @@`
 }
 
+type LetDecl struct {
+    Pos lexer.Position
+
+    LetSpec LetSpec `"let" @@`
+}
+
 type ScanStmt struct {
     Pos lexer.Position
 
     RefList []Reference `"scan" @@ ( "," @@ )*`
+
+    // Sep, if set, separates the values within a single token, which is split
+    // into one field per value. Width, if set instead, splits the token into
+    // fields of that many characters.
+    Sep   string `( "sep" @String`
+    Width *Expr  `| "width" @@ )?`
+
+    // Format, if "strict" or "lax", overrides the numeric format that the
+    // evaluation requires of the tokens scanned by this statement.
+    Format string `@( "strict" | "lax" )?`
 }
 
 type ScanlnStmt struct {
@@ -69,6 +97,13 @@ type ForStmt struct {
     Block  Block        `@@ "end"`
 }
 
+type SubtaskStmt struct {
+    Pos lexer.Position
+
+    Name  string `"subtask" @String EOL+`
+    Block Block  `@@ "end"`
+}
+
 type EOLStmt struct {
     Pos lexer.Position
 
@@ -81,6 +116,11 @@ type EOFStmt struct {
     EOF bool `@"eof"`
 }
 
+type ConstSpec struct {
+    Ident string `@Ident "="`
+    Value Expr   `@@`
+}
+
 type VarSpec struct {
     IdentList []string `@Ident ( "," @Ident )*`
     Type      Type     `@@`
@@ -89,7 +129,9 @@ type VarSpec struct {
 type LetSpec struct {
     IdentList []string `@Ident ( "," @Ident )*`
     Type      Type     `@@`
-    Reducer   string   `":" Ident`
+    Reducer   string   `":" @Ident`
+    Source    string   `"(" @Ident ")"`
+    Check     []Expr   `( "check" @@ ( "," @@ )* )?`
 }
 
 type Type struct {
@@ -106,11 +148,27 @@ type ArrayType struct {
     ElementType Type `@@`
 }
 
+// Dims returns the length of each dimension of n, outermost first, and the
+// name of its element type.
+func (n *ArrayType) Dims() ([]*Expr, string) {
+    dims := []*Expr{}
+    t := &Type{TypeLit: &TypeLit{ArrayType: n}}
+    for t.TypeLit != nil {
+        dims = append(dims, &t.TypeLit.ArrayType.ArrayLength)
+        t = &t.TypeLit.ArrayType.ElementType
+    }
+    return dims, *t.TypeName
+}
+
+// Reference is a variable to scan into, possibly indexed. If High is set, the
+// last index is instead the low end of a slice of an array, as in A[a:b], whose
+// elements are scanned in turn.
 type Reference struct {
     Pos lexer.Position
 
     Ident   string `@Ident`
-    Indices []Expr `( "[" @@ "]" )*`
+    Indices []Expr `( "[" @@ ( "]"`
+    High    *Expr  `| ":" @@ "]" (?! "[") ) )*`
 }
 
 type Expr struct {
@@ -131,20 +189,21 @@ type LogicalOr struct {
 type OpLogicalOr struct {
     Pos lexer.Position
 
-    LogicalOr *LogicalOr `"|" "|" @@`
+    LogicalOr *LogicalOr `"||" @@`
 }
 
 type LogicalAnd struct {
     Pos lexer.Position
 
-    Left  *Relative     `@@`
-    Right []*OpRelative `@@*`
+    Left     *Relative     `@@`
+    Right    []*OpRelative `( @@+`
+    Interval *Interval     `| "in" @@ )?`
 }
 
 type OpLogicalAnd struct {
     Pos lexer.Position
 
-    LogicalAnd *LogicalAnd `"&" "&" @@`
+    LogicalAnd *LogicalAnd `"&&" @@`
 }
 
 type Relative struct {
@@ -155,10 +214,19 @@ type Relative struct {
 type OpRelative struct {
     Pos lexer.Position
 
-    Operator Operator  `@("=" "=" | "!" "=" | "<" "=" | ">" "=" | "<" | ">")`
+    Operator Operator  `@("==" | "!=" | "<=" | ">=" | "<" | ">")`
     Relative *Relative `@@`
 }
 
+type Interval struct {
+    Pos lexer.Position
+
+    LowClosed  bool `( @"[" | "(" )`
+    Low        Expr `@@ ","`
+    High       Expr `@@`
+    HighClosed bool `( @"]" | ")" )`
+}
+
 type Addition struct {
     Left  *Multiplication     `@@`
     Right []*OpMultiplication `@@*`
@@ -167,7 +235,7 @@ type Addition struct {
 type OpAddition struct {
     Pos lexer.Position
 
-    Operator Operator  `@("+" | "-")`
+    Operator Operator  `@("+" | "-" | "|" | "xor")`
     Addition *Addition `@@`
 }
 
@@ -179,28 +247,83 @@ type Multiplication struct {
 type OpMultiplication struct {
     Pos lexer.Position
 
-    Operator Operator        `@("*" | "/")`
+    Operator Operator        `@("*" | "/" | "%" | "<<" | ">>" | "&")`
     Factor   *Multiplication `@@`
 }
 
 type Unary struct {
     Value   *Primary `( "+"? @@`
-    Negated *Primary `| "-" @@ )`
+    Negated *Primary `| "-" @@`
+    Not     *Primary `| "!" @@ )`
 }
 
 type Primary struct {
     Pos lexer.Position
 
-    BasicLit *BasicLit `  @@`
-    CallExpr *CallExpr `| @@`
-    Variable *Variable `| @@`
-    SubExpr  *Expr     `| "(" @@ ")"`
+    BasicLit   *BasicLit   `  @@`
+    Conversion *Conversion `| @@`
+    Quantifier *Quantifier `| @@`
+    CallExpr   *CallExpr   `| @@`
+    Variable   *Variable   `| @@`
+    SubExpr    *Expr       `| "(" @@ ")"`
 }
 
 type BasicLit struct {
     FloatLit  *float64 `  @Float`
-    IntLit    *int64   `| @Int`
+    IntLit    *Integer `| @Int`
     StringLit *string  `| @String`
+    CharLit   *Char    `| @Char`
+    BoolLit   *Boolean `| @("true" | "false")`
+}
+
+// Integer is an integer literal. Digits may be separated by underscores
+// (1_000_000) and followed by a non-negative decimal exponent (2e18).
+type Integer int64
+
+func (i *Integer) Capture(s []string) error {
+    m, e := strings.ReplaceAll(s[0], "_", ""), "0"
+    if j := strings.IndexAny(m, "eE"); j >= 0 {
+        m, e = m[:j], strings.TrimPrefix(m[j+1:], "+")
+    }
+    v, err := strconv.ParseInt(m, 10, 64)
+    if err != nil {
+        return err
+    }
+    x, err := strconv.Atoi(e)
+    if err != nil {
+        return err
+    }
+    for ; x > 0 && v != 0; x-- {
+        if v > math.MaxInt64/10 {
+            return fmt.Errorf("integer literal %s overflows int64", s[0])
+        }
+        v *= 10
+    }
+    *i = Integer(v)
+    return nil
+}
+
+// Char is a character literal. It denotes a single ASCII character, written
+// as in Go ('a', '\n', '\'').
+type Char byte
+
+func (c *Char) Capture(s []string) error {
+    v, _, t, err := strconv.UnquoteChar(s[0][1:len(s[0])-1], '\'')
+    if err != nil {
+        return err
+    }
+    if t != "" || v > 127 {
+        return fmt.Errorf("character literal %s is not a single ASCII character", s[0])
+    }
+    *c = Char(v)
+    return nil
+}
+
+type Boolean bool
+
+func (b *Boolean) Capture(s []string) error {
+    *b = s[0] == "true"
+    return nil
 }
 
 type Operator string
@@ -211,14 +334,34 @@ func (o *Operator) Capture(s []string) error {
 }
 
 type RangeClause struct {
-    Index string `@Ident ":" "="`
-    Low   Expr   `@@ "." "." "."`
+    Index string `@Ident ":="`
+    Low   Expr   `@@ "..."`
     High  Expr   `@@`
 }
 
+// Variable is a variable, possibly indexed. If High is set, the last index is
+// instead the low end of a slice, as in s[a:b], and no index may follow it.
 type Variable struct {
     Ident   string `@Ident`
-    Indices []Expr `( "[" @@ "]" )?`
+    Indices []Expr `( "[" @@ ( "]"`
+    High    *Expr  `| ":" @@ "]" (?! "[") ) )*`
+
+    Tokens []lexer.Token
+}
+
+type Conversion struct {
+    Type string `@Type`
+    Expr Expr   `"(" @@ ")"`
+}
+
+type Quantifier struct {
+    Pos lexer.Position
+
+    Kind  string `@("all" | "exists") "("`
+    Index string `@Ident "in"`
+    Low   Expr   `@@ "..."`
+    High  Expr   `@@ ":"`
+    Expr  Expr   `@@ ")"`
 }
 
 type CallExpr struct {
@@ -229,16 +372,21 @@ type CallExpr struct {
 func (Source) node()           {}
 func (Block) node()            {}
 func (Statement) node()        {}
+func (ConstDecl) node()        {}
 func (VarDecl) node()          {}
+func (LetDecl) node()          {}
 func (ScanStmt) node()         {}
 func (ScanlnStmt) node()       {}
 func (CheckStmt) node()        {}
 func (IfStmt) node()           {}
 func (IfBranch) node()         {}
 func (ForStmt) node()          {}
+func (SubtaskStmt) node()      {}
 func (EOLStmt) node()          {}
 func (EOFStmt) node()          {}
+func (ConstSpec) node()        {}
 func (VarSpec) node()          {}
+func (LetSpec) node()          {}
 func (Type) node()             {}
 func (TypeLit) node()          {}
 func (ArrayType) node()        {}
@@ -250,6 +398,7 @@ func (LogicalAnd) node()       {}
 func (OpLogicalAnd) node()     {}
 func (Relative) node()         {}
 func (OpRelative) node()       {}
+func (Interval) node()         {}
 func (Addition) node()         {}
 func (OpAddition) node()       {}
 func (Multiplication) node()   {}
@@ -259,4 +408,6 @@ func (Primary) node()          {}
 func (BasicLit) node()         {}
 func (RangeClause) node()      {}
 func (Variable) node()         {}
+func (Conversion) node()       {}
+func (Quantifier) node()       {}
 func (CallExpr) node()         {}
@@ -8,12 +8,14 @@ import (
 var parser = participle.MustBuild[Source](participle.Lexer(lexer.MustSimple([]lexer.SimpleRule{
 	{"comment", `#[^\n]*`},
 	{"whitespace", `[ \t]+`},
-	{"Float", `\d+\.\d*`},
-	{"Int", `\d+`},
+	{"Float", `\d+(_\d+)*\.\d+(_\d+)*([eE][-+]?\d+)?|\d+(_\d+)*[eE]-\d+`},
+	{"Int", `\d+(_\d+)*([eE]\+?\d+)?`},
 	{"String", `"(\\"|[^"])*"`},
-	{"Keyword", `end|eof|eol|for|scanln|scan|var`},
-	{"Type", `\b(bool|float32|float64|int|int64|string)\b`},
+	{"Char", `'(\\.|[^'\\])'`},
+	{"Keyword", `\b(const|end|eof|eol|for|let|scanln|scan|var)\b`},
+	{"Type", `\b(bigint|bool|char|decimal|float32|float64|int|int64|string|uint32|uint64)\b`},
 	{"Ident", `[a-zA-Z_][a-zA-Z0-9_]*`},
+	{"Operator", `\|\||&&|==|!=|<=|>=|<<|>>|:=|\.\.\.`},
 	{"Punct", `[-[!@#$%^&*()+_={}\|:;"'<,>.?/]|]`},
 	{"EOL", `[\n\r]+`},
 })),
@@ -0,0 +1,72 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+func TestLexNumbers(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want []string
+	}{
+		{"1.5", []string{"Float 1.5"}},
+		{"1_000.25", []string{"Float 1_000.25"}},
+		{"2.5e-3", []string{"Float 2.5e-3"}},
+		{"2e-3", []string{"Float 2e-3"}},
+		{"2e18", []string{"Int 2e18"}},
+		{"1_000_000", []string{"Int 1_000_000"}},
+		// As in Go, an underscore must separate two digits.
+		{"1_", []string{"Int 1", "Ident _"}},
+		{"1__0", []string{"Int 1", "Ident __0"}},
+		{"1_.5", []string{"Int 1", "Ident _", "Punct .", "Int 5"}},
+		{"1._5", []string{"Int 1", "Punct .", "Ident _5"}},
+	} {
+		testLex(t, c.src, c.want)
+	}
+}
+
+func TestLexRanges(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want []string
+	}{
+		// A decimal point must be followed by a digit, so that a range
+		// written without spaces does not begin with a float.
+		{"0...n", []string{"Int 0", "Operator ...", "Ident n"}},
+		{"1.", []string{"Int 1", "Punct ."}},
+	} {
+		testLex(t, c.src, c.want)
+	}
+}
+
+// testLex checks that src lexes to the tokens want, each given as its symbol
+// name and value.
+func testLex(t *testing.T, src string, want []string) {
+	t.Helper()
+	tokens, err := parser.Lex("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("%q: %v", src, err)
+	}
+	got := []string{}
+	for _, tok := range tokens {
+		if tok.EOF() {
+			break
+		}
+		got = append(got, symbolName(tok.Type)+" "+tok.Value)
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%q: got %q, want %q", src, got, want)
+	}
+}
+
+func symbolName(tt lexer.TokenType) string {
+	for name, t := range parser.Lexer().Symbols() {
+		if t == tt {
+			return name
+		}
+	}
+	return ""
+}
@@ -23,8 +23,12 @@ func Walk(v Visitor, n Node) {
 
     case *Statement:
         switch {
+        case n.ConstDecl != nil:
+            Walk(v, n.ConstDecl)
         case n.VarDecl != nil:
             Walk(v, n.VarDecl)
+        case n.LetDecl != nil:
+            Walk(v, n.LetDecl)
         case n.ScanStmt != nil:
             Walk(v, n.ScanStmt)
         case n.ScanlnStmt != nil:
@@ -35,6 +39,8 @@ func Walk(v Visitor, n Node) {
             Walk(v, n.IfStmt)
         case n.ForStmt != nil:
             Walk(v, n.ForStmt)
+        case n.SubtaskStmt != nil:
+            Walk(v, n.SubtaskStmt)
         case n.EOLStmt != nil:
             Walk(v, n.EOLStmt)
         case n.EOFStmt != nil:
@@ -44,10 +50,19 @@ func Walk(v Visitor, n Node) {
     case *VarDecl:
         Walk(v, &n.VarSpec)
 
+    case *ConstDecl:
+        Walk(v, &n.ConstSpec)
+
+    case *LetDecl:
+        Walk(v, &n.LetSpec)
+
     case *ScanStmt:
         for i := range n.RefList {
             Walk(v, &n.RefList[i])
         }
+        if n.Width != nil {
+            Walk(v, n.Width)
+        }
 
     case *ScanlnStmt:
         for i := range n.RefList {
@@ -65,7 +80,9 @@ func Walk(v Visitor, n Node) {
         }
 
     case *IfBranch:
-        Walk(v, n.Condition)
+        if n.Condition != nil {
+            Walk(v, n.Condition)
+        }
         Walk(v, &n.Block)
 
     case *ForStmt:
@@ -79,6 +96,9 @@ func Walk(v Visitor, n Node) {
         }
         Walk(v, &n.Block)
 
+    case *SubtaskStmt:
+        Walk(v, &n.Block)
+
     case *EOLStmt:
 
     case *EOFStmt:
@@ -86,8 +106,19 @@ func Walk(v Visitor, n Node) {
     case *VarSpec:
         Walk(v, &n.Type)
 
+    case *ConstSpec:
+        Walk(v, &n.Value)
+
+    case *LetSpec:
+        Walk(v, &n.Type)
+        for i := range n.Check {
+            Walk(v, &n.Check[i])
+        }
+
     case *Type:
-        Walk(v, n.TypeLit)
+        if n.TypeLit != nil {
+            Walk(v, n.TypeLit)
+        }
 
     case *TypeLit:
         Walk(v, n.ArrayType)
@@ -115,6 +146,9 @@ func Walk(v Visitor, n Node) {
         for _, n := range n.Right {
             Walk(v, n)
         }
+        if n.Interval != nil {
+            Walk(v, n.Interval)
+        }
 
     case *OpLogicalAnd:
         Walk(v, n.LogicalAnd)
@@ -128,6 +162,10 @@ func Walk(v Visitor, n Node) {
     case *OpRelative:
         Walk(v, n.Relative)
 
+    case *Interval:
+        Walk(v, &n.Low)
+        Walk(v, &n.High)
+
     case *Addition:
         Walk(v, n.Left)
         for _, n := range n.Right {
@@ -152,12 +190,18 @@ func Walk(v Visitor, n Node) {
             Walk(v, n.Value)
         case n.Negated != nil:
             Walk(v, n.Negated)
+        case n.Not != nil:
+            Walk(v, n.Not)
         }
 
     case *Primary:
         switch {
         // case n.BasicLit != nil:
         //     Walk(v, n.BasicLit)
+        case n.Conversion != nil:
+            Walk(v, n.Conversion)
+        case n.Quantifier != nil:
+            Walk(v, n.Quantifier)
         case n.CallExpr != nil:
             Walk(v, n.CallExpr)
         case n.Variable != nil:
@@ -166,10 +210,33 @@ func Walk(v Visitor, n Node) {
             Walk(v, n.SubExpr)
         }
 
+    case *Quantifier:
+        Walk(v, &n.Low)
+        Walk(v, &n.High)
+        Walk(v, &n.Expr)
+
     case *Variable:
         for i := range n.Indices {
             Walk(v, &n.Indices[i])
         }
+        if n.High != nil {
+            Walk(v, n.High)
+        }
+
+    case *Reference:
+        for i := range n.Indices {
+            Walk(v, &n.Indices[i])
+        }
+        if n.High != nil {
+            Walk(v, n.High)
+        }
+
+    case *RangeClause:
+        Walk(v, &n.Low)
+        Walk(v, &n.High)
+
+    case *Conversion:
+        Walk(v, &n.Expr)
 
     case *CallExpr:
         for i := range n.Args {
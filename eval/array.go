@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"math/big"
+	"reflect"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// ArrayFunctions check a property of the array a, given any further arguments
+// in args. Along with the outcome, they return the index of the first element
+// found to violate the property, or -1 if no single element is to blame.
+var ArrayFunctions = map[string]func(a reflect.Value, args ...interface{}) (bool, int, error){
+	"distinct": func(a reflect.Value, args ...interface{}) (bool, int, error) {
+		if a.Type().Elem().Kind() == reflect.Slice {
+			// Rows are not comparable; distinct looks at one dimension.
+			return false, -1, ErrInvalidArgument{}
+		}
+		seen := map[interface{}]bool{}
+		for i := 0; i < a.Len(); i++ {
+			x := a.Index(i).Interface()
+			switch v := x.(type) {
+			case *big.Int:
+				x = v.String()
+			case Decimal:
+				x = v.rat().RatString()
+			}
+			if seen[x] {
+				return false, i, nil
+			}
+			seen[x] = true
+		}
+		return true, -1, nil
+	},
+
+	"sorted": func(a reflect.Value, args ...interface{}) (bool, int, error) {
+		return ordered(a, "<=")
+	},
+
+	"sortedDesc": func(a reflect.Value, args ...interface{}) (bool, int, error) {
+		return ordered(a, ">=")
+	},
+
+	"isPermutation": func(a reflect.Value, args ...interface{}) (bool, int, error) {
+		if len(args) != 1 {
+			return false, -1, ErrInvalidArgument{}
+		}
+		n, ok := toInt(args[0])
+		if !ok {
+			return false, -1, ErrInvalidArgument{}
+		}
+		if a.Len() != n {
+			return false, -1, nil
+		}
+		seen := make([]bool, n+1)
+		for i := 0; i < a.Len(); i++ {
+			x, ok := toInt64(a.Index(i).Interface())
+			if !ok {
+				return false, -1, ErrInvalidArgument{}
+			}
+			if x < 1 || x > int64(n) || seen[x] {
+				return false, i, nil
+			}
+			seen[x] = true
+		}
+		return true, -1, nil
+	},
+}
+
+// Element is an element of an array.
+type Element struct {
+	Array string
+	Index int
+	Value interface{}
+}
+
+// ordered reports whether every element of a compares op to the next one.
+func ordered(a reflect.Value, op ast.Operator) (bool, int, error) {
+	for i := 1; i < a.Len(); i++ {
+		ok, err := compare(lexer.Position{}, op, a.Index(i-1).Interface(), a.Index(i).Interface())
+		if err != nil {
+			return false, -1, ErrInvalidArgument{}
+		}
+		if !ok {
+			return false, i, nil
+		}
+	}
+	return true, -1, nil
+}
+
+func (e *evaluator) arrayCall(n *ast.Primary) (interface{}, error) {
+	if len(n.CallExpr.Args) == 0 {
+		return nil, ErrInvalidArgument{}
+	}
+	args := []interface{}{}
+	for i := range n.CallExpr.Args {
+		v, err := e.expr(&n.CallExpr.Args[i])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, defaultType(v))
+	}
+	a := reflect.ValueOf(args[0])
+	if a.Kind() != reflect.Slice {
+		return nil, ErrInvalidArgument{}
+	}
+
+	ok, i, err := ArrayFunctions[n.CallExpr.Ident](a, args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	if !ok && i >= 0 {
+		name := ""
+		ast.Inspect(&n.CallExpr.Args[0], func(n ast.Node) bool {
+			if n, ok := n.(*ast.Variable); ok && name == "" {
+				name = string(formatTokens(n.Tokens))
+			}
+			return name == ""
+		})
+		e.element = &Element{Array: name, Index: i, Value: a.Index(i).Interface()}
+		if c, ok := e.cursors[a.Index(i).Addr().Pointer()]; ok {
+			e.witness = &c
+		}
+	}
+	return ok, nil
+}
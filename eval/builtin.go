@@ -3,27 +3,36 @@ package eval
 import (
 	"errors"
 	"math"
+	"math/big"
+	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"github.com/alecthomas/participle/v2/lexer"
 )
 
 var Functions = map[string]func(args ...interface{}) (interface{}, error){
 	"len": func(args ...interface{}) (interface{}, error) {
-		s, ok := args[0].(string)
-		if !ok {
+		if len(args) != 1 {
+			return nil, ErrInvalidArgument{}
+		}
+		switch v := args[0].(type) {
+		case string:
+			return len(v), nil
+		}
+		v := reflect.ValueOf(args[0])
+		if v.Kind() != reflect.Slice {
 			return nil, ErrInvalidArgument{}
 		}
-		return len(s), nil
+		return v.Len(), nil
 	},
 
 	"re": func(args ...interface{}) (interface{}, error) {
-		s, ok := args[0].(string)
-		if !ok {
-			return nil, ErrInvalidArgument{}
-		}
-		expr, ok := args[1].(string)
-		if !ok {
-			return nil, ErrInvalidArgument{}
+		s, expr, err := reArgs(args)
+		if err != nil {
+			return nil, err
 		}
 		re, err := regexp.Compile(expr)
 		if err != nil {
@@ -33,40 +42,154 @@ var Functions = map[string]func(args ...interface{}) (interface{}, error){
 	},
 
 	"pow": func(args ...interface{}) (interface{}, error) {
-		switch n := args[0].(type) {
-		case int:
-			exp, ok := toInt(args[1])
-			if !ok {
+		if len(args) != 2 {
+			return nil, ErrInvalidArgument{}
+		}
+		return pow(args[0], args[1])
+	},
+
+	"sum": sum,
+
+	"min": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, ErrInvalidArgument{}
+		}
+		return extreme("<", args[0])
+	},
+
+	"max": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, ErrInvalidArgument{}
+		}
+		return extreme(">", args[0])
+	},
+
+	"count": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, ErrInvalidArgument{}
+		}
+		a := reflect.ValueOf(args[0])
+		if a.Kind() != reflect.Slice {
+			return nil, ErrInvalidArgument{}
+		}
+		n := 0
+		for i := 0; i < a.Len(); i++ {
+			eq, err := compare(lexer.Position{}, "==", a.Index(i).Interface(), args[1])
+			if err != nil {
 				return nil, ErrInvalidArgument{}
 			}
-			if exp >= 0 {
-				return powInt(n, exp), nil
+			if eq {
+				n++
 			}
+		}
+		return n, nil
+	},
 
-		case int64:
-			exp, ok := toInt64(args[1])
-			if !ok {
+	"digits": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, ErrInvalidArgument{}
+		}
+		if d, ok := args[0].(Decimal); ok {
+			if d.Scale < 0 {
 				return nil, ErrInvalidArgument{}
 			}
-			if exp >= 0 {
-				return powInt64(n, exp), nil
+			return d.Scale, nil
+		}
+		if _, ok := toBigInt(args[0]); ok {
+			return 0, nil
+		}
+		return nil, ErrInvalidArgument{}
+	},
+
+	"isLower":  onlyBytes(func(c byte) bool { return 'a' <= c && c <= 'z' }),
+	"isUpper":  onlyBytes(func(c byte) bool { return 'A' <= c && c <= 'Z' }),
+	"isDigits": onlyBytes(func(c byte) bool { return '0' <= c && c <= '9' }),
+
+	"onlyChars": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 2)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		for i := 0; i < len(s[0]); i++ {
+			if strings.IndexByte(s[1], s[0][i]) < 0 {
+				return false, nil
 			}
 		}
+		return true, nil
+	},
 
-		n, ok := toFloat64(args[0])
+	"countChar": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, ErrInvalidArgument{}
+		}
+		s, ok := args[0].(string)
 		if !ok {
 			return nil, ErrInvalidArgument{}
 		}
-		exp, ok := toFloat64(args[1])
+		c, ok := args[1].(byte)
 		if !ok {
 			return nil, ErrInvalidArgument{}
 		}
-		return math.Pow(n, exp), nil
+		return strings.Count(s, string(c)), nil
 	},
 
-	"sum": sum,
+	"contains": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 2)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return strings.Contains(s[0], s[1]), nil
+	},
+
+	"hasPrefix": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 2)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return strings.HasPrefix(s[0], s[1]), nil
+	},
+
+	"hasSuffix": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 2)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return strings.HasSuffix(s[0], s[1]), nil
+	},
+
+	"upper": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 1)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return strings.ToUpper(s[0]), nil
+	},
+
+	"lower": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 1)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return strings.ToLower(s[0]), nil
+	},
+
+	"isPalindrome": func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 1)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		for i, j := 0, len(s[0])-1; i < j; i, j = i+1, j-1 {
+			if s[0][i] != s[0][j] {
+				return false, nil
+			}
+		}
+		return true, nil
+	},
 
 	"toInt64": func(args ...interface{}) (interface{}, error) {
+		if len(args) == 0 || len(args) > 2 {
+			return nil, ErrInvalidArgument{}
+		}
 		switch n := args[0].(type) {
 		case int:
 			return int64(n), nil
@@ -88,23 +211,134 @@ var Functions = map[string]func(args ...interface{}) (interface{}, error){
 	},
 }
 
-func powInt(n int, exp int) int {
-	r := 1
-	for {
-		if exp&1 > 0 {
-			r *= n
+// onlyBytes returns a function that reports whether every byte of its string
+// argument satisfies f. It is true of the empty string.
+func onlyBytes(f func(c byte) bool) func(args ...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		s, ok := stringArgs(args, 1)
+		if !ok {
+			return nil, ErrInvalidArgument{}
 		}
-		exp >>= 1
-		if exp == 0 {
-			break
+		for i := 0; i < len(s[0]); i++ {
+			if !f(s[0][i]) {
+				return false, nil
+			}
 		}
-		n *= n
+		return true, nil
 	}
-	return r
 }
 
-func powInt64(n int64, exp int64) int64 {
-	var r int64 = 1
+// stringArgs returns args as strings, provided that there are n of them and
+// all are strings.
+func stringArgs(args []interface{}, n int) ([]string, bool) {
+	if len(args) != n {
+		return nil, false
+	}
+	s := make([]string, n)
+	for i, a := range args {
+		v, ok := a.(string)
+		if !ok {
+			return nil, false
+		}
+		s[i] = v
+	}
+	return s, true
+}
+
+// reArgs returns the string and the regular expression re is called with.
+func reArgs(args []interface{}) (s, expr string, err error) {
+	if len(args) != 2 {
+		return "", "", ErrInvalidArgument{}
+	}
+	s, sok := args[0].(string)
+	expr, xok := args[1].(string)
+	if !sok || !xok {
+		return "", "", ErrInvalidArgument{}
+	}
+	return s, expr, nil
+}
+
+func pow(n, exp interface{}) (interface{}, error) {
+	if nu, ok := n.(untypedInt); ok {
+		if expu, ok := exp.(untypedInt); ok && expu >= 0 {
+			v, err := powBig(big.NewInt(int64(nu)), int64(expu))
+			if err != nil {
+				return nil, err
+			}
+			return bigResult(v), nil
+		}
+	}
+	// An untyped base takes the type of the exponent, if it can.
+	if t := typeName(exp); isUntyped(typeName(n)) && !isUntyped(t) {
+		if v, ok := convert(n, t); ok {
+			n = v
+		}
+	}
+	n, exp = defaultType(n), defaultType(exp)
+
+	switch n := n.(type) {
+	case int:
+		exp, ok := toInt(exp)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		if exp >= 0 {
+			return powInteger(n, exp), nil
+		}
+
+	case int64:
+		exp, ok := toInt64(exp)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		if exp >= 0 {
+			return powInteger(n, exp), nil
+		}
+
+	case uint32:
+		exp, ok := toUint32(exp)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return powInteger(n, exp), nil
+
+	case uint64:
+		exp, ok := toUint64(exp)
+		if !ok {
+			return nil, ErrInvalidArgument{}
+		}
+		return powInteger(n, exp), nil
+
+	case *big.Int:
+		exp, ok := toInt64(exp)
+		if !ok || exp < 0 {
+			return nil, ErrInvalidArgument{}
+		}
+		return powBig(n, exp)
+	}
+
+	nf, ok := toFloat64(n)
+	if !ok {
+		return nil, ErrInvalidArgument{}
+	}
+	expf, ok := toFloat64(exp)
+	if !ok {
+		return nil, ErrInvalidArgument{}
+	}
+	return math.Pow(nf, expf), nil
+}
+
+// powBig returns n raised to the non-negative power exp, failing if the result
+// would exceed maxBigBits.
+func powBig(n *big.Int, exp int64) (*big.Int, error) {
+	if n.CmpAbs(big.NewInt(1)) > 0 && exp > maxBigBits/int64(n.BitLen()-1) {
+		return nil, ErrInvalidArgument{}
+	}
+	return new(big.Int).Exp(n, big.NewInt(exp), nil), nil
+}
+
+func powInteger[T int | int64 | uint32 | uint64](n T, exp T) T {
+	var r T = 1
 	for {
 		if exp&1 > 0 {
 			r *= n
@@ -119,28 +353,45 @@ func powInt64(n int64, exp int64) int64 {
 }
 
 func sum(args ...interface{}) (interface{}, error) {
-	var r interface{} = 0
+	var r interface{} = untypedInt(0)
+	add := func(v interface{}) error {
+		var err error
+		r, err = binaryOp(lexer.Position{}, "+", r, v)
+		return err
+	}
 	for _, a := range args {
-		switch a := a.(type) {
-		case int:
-			ri, _ := toInt(r)
-			r = ri + a
-
-		case int64:
-			ri, _ := toInt64(r)
-			r = ri + a
-
-		case []int:
-			args := []interface{}{}
-			for _, v := range a {
-				args = append(args, v)
+		v := reflect.ValueOf(a)
+		if v.Kind() != reflect.Slice {
+			if err := add(a); err != nil {
+				return nil, ErrInvalidArgument{}
 			}
-			s, err := sum(args...)
-			if err != nil {
-				return 0, err
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := add(v.Index(i).Interface()); err != nil {
+				return nil, ErrInvalidArgument{}
 			}
-			ri, _ := toInt(r)
-			r = ri + s.(int)
+		}
+	}
+	return r, nil
+}
+
+// extreme returns the element of array a that compares op to every other
+// element: the least for "<" and the greatest for ">".
+func extreme(op ast.Operator, a interface{}) (interface{}, error) {
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return nil, ErrInvalidArgument{}
+	}
+	r := v.Index(0).Interface()
+	for i := 1; i < v.Len(); i++ {
+		x := v.Index(i).Interface()
+		ok, err := compare(lexer.Position{}, op, x, r)
+		if err != nil {
+			return nil, ErrInvalidArgument{}
+		}
+		if ok {
+			r = x
 		}
 	}
 	return r, nil
@@ -1,5 +1,232 @@
 package eval
 
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// Numeric literals in a scanspec are untyped constants, as in Go. Before a
+// binary operation or a comparison, both operands are brought to a common type
+// as follows:
+//
+//   - An untyped constant meeting a typed operand takes the type of that
+//     operand. The constant must be representable in it: 1.5 cannot become
+//     an int.
+//   - Two untyped constants stay untyped. The result is an untyped float if
+//     either of them is one.
+//   - Two typed numeric operands of different types are promoted to the wider
+//     of the two, in the order char, int, uint32, int64, uint64, bigint,
+//     decimal, float32, float64. An unsigned integer meeting a signed one is
+//     promoted further to hold both: int and uint32 to int64, and int or
+//     int64 and uint64 to bigint.
+//
+// An untyped integer constant that does not fit in int64, such as 10^100, is a
+// bigint instead. An untyped floating-point constant meeting a decimal takes
+// the shortest decimal form that denotes it, so that 0.1 is exactly 0.1.
+//
+// An untyped constant that is used where no other operand decides its type,
+// such as a built-in function argument, takes its default type: int for
+// integer constants and float64 for floating-point ones.
+
+const (
+	UntypedInt   = "untyped int"
+	UntypedFloat = "untyped float"
+)
+
+type untypedInt int64
+
+type untypedFloat float64
+
+var numericRank = map[string]int{
+	"char":    1,
+	"int":     2,
+	"uint32":  3,
+	"int64":   4,
+	"uint64":  5,
+	"bigint":  6,
+	"decimal": 7,
+	"float32": 8,
+	"float64": 9,
+}
+
+// signedOver gives the narrowest signed type that holds an unsigned type.
+var signedOver = map[string]string{
+	"uint32": "int64",
+	"uint64": "bigint",
+}
+
+func isSigned(t string) bool {
+	return t == "int" || t == "int64"
+}
+
+// Promote returns the type operands of types a and b are converted to before a
+// binary operation. Types are named as in Types, or UntypedInt and
+// UntypedFloat. It reports false if the operands cannot be combined.
+func Promote(a, b string) (string, bool) {
+	au, bu := isUntyped(a), isUntyped(b)
+	switch {
+	case a == b:
+		return a, true
+	case au && bu:
+		return UntypedFloat, true
+	case au:
+		return b, numericRank[b] > 0
+	case bu:
+		return a, numericRank[a] > 0
+	case numericRank[a] > 0 && numericRank[b] > 0:
+		if numericRank[a] < numericRank[b] {
+			a, b = b, a
+		}
+		if s, ok := signedOver[a]; ok && isSigned(b) {
+			return s, true
+		}
+		return a, true
+	}
+	return "", false
+}
+
+func isUntyped(t string) bool {
+	return t == UntypedInt || t == UntypedFloat
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case byte:
+		return "char"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case uint32:
+		return "uint32"
+	case uint64:
+		return "uint64"
+	case *big.Int:
+		return "bigint"
+	case Decimal:
+		return "decimal"
+	case float32:
+		return "float32"
+	case float64:
+		return "float64"
+	case string:
+		return "string"
+	case untypedInt:
+		return UntypedInt
+	case untypedFloat:
+		return UntypedFloat
+	}
+	return ""
+}
+
+// promote converts l and r to their common type.
+func promote(l, r interface{}) (interface{}, interface{}, bool) {
+	t, ok := Promote(typeName(l), typeName(r))
+	if !ok {
+		return nil, nil, false
+	}
+	l, lok := convert(l, t)
+	r, rok := convert(r, t)
+	return l, r, lok && rok
+}
+
+// convert converts v to type t without losing information.
+func convert(v interface{}, t string) (interface{}, bool) {
+	switch t {
+	case "bool":
+		return toBool(v)
+	case "char":
+		return toChar(v)
+	case "int":
+		return toInt(v)
+	case "int64":
+		return toInt64(v)
+	case "uint32":
+		return toUint32(v)
+	case "uint64":
+		return toUint64(v)
+	case "bigint":
+		return toBigInt(v)
+	case "decimal":
+		return toDecimal(v)
+	case "float32":
+		return toFloat32(v)
+	case "float64":
+		return toFloat64(v)
+	case "string":
+		return toString(v)
+	case UntypedInt:
+		v, ok := v.(untypedInt)
+		return v, ok
+	case UntypedFloat:
+		switch v := v.(type) {
+		case untypedInt:
+			return untypedFloat(v), true
+		case untypedFloat:
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// cast converts v to type t as an explicit conversion does. Unlike convert,
+// floating-point values are truncated toward zero when t is an integer type.
+func cast(v interface{}, t string) (interface{}, bool) {
+	if numericRank[t] > 0 && numericRank[t] <= 6 {
+		switch f := v.(type) {
+		case float32:
+			v = untypedFloat(math.Trunc(float64(f)))
+		case float64:
+			v = untypedFloat(math.Trunc(f))
+		case untypedFloat:
+			v = untypedFloat(math.Trunc(float64(f)))
+		}
+	}
+	return convert(v, t)
+}
+
+// untyped converts a Go value to the constant it denotes. Numbers become
+// untyped constants.
+func untyped(v interface{}) (interface{}, bool) {
+	if b, ok := v.(*big.Int); ok {
+		return bigResult(b), true
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Bool, reflect.String:
+		return v, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return untypedInt(r.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return bigResult(new(big.Int).SetUint64(r.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return untypedFloat(r.Float()), true
+	}
+	return nil, false
+}
+
+// constant returns a pointer to v, as kept in Values.
+func constant(v interface{}) reflect.Value {
+	c := reflect.New(reflect.TypeOf(v))
+	c.Elem().Set(reflect.ValueOf(v))
+	return c
+}
+
+// defaultType gives an untyped constant its default type.
+func defaultType(v interface{}) interface{} {
+	switch v := v.(type) {
+	case untypedInt:
+		return int(v)
+	case untypedFloat:
+		return float64(v)
+	}
+	return v
+}
+
 func toBool(v interface{}) (bool, bool) {
 	switch v := v.(type) {
 	case bool:
@@ -8,50 +235,218 @@ func toBool(v interface{}) (bool, bool) {
 	return false, false
 }
 
+func toChar(v interface{}) (byte, bool) {
+	switch v := v.(type) {
+	case byte:
+		return v, true
+	case untypedInt:
+		if 0 <= v && v <= math.MaxUint8 {
+			return byte(v), true
+		}
+	case untypedFloat:
+		if v == untypedFloat(math.Trunc(float64(v))) && 0 <= v && v <= math.MaxUint8 {
+			return byte(v), true
+		}
+	}
+	return 0, false
+}
+
 func toInt(v interface{}) (int, bool) {
 	switch v := v.(type) {
+	case byte:
+		return int(v), true
 	case int:
 		return v, true
 	case int64:
 		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int(v), true
+		}
+	case *big.Int:
+		if v.IsInt64() {
+			return int(v.Int64()), true
+		}
+	case untypedInt:
+		return int(v), true
+	case untypedFloat:
+		if v == untypedFloat(math.Trunc(float64(v))) {
+			return int(v), true
+		}
 	}
 	return 0, false
 }
 
 func toInt64(v interface{}) (int64, bool) {
 	switch v := v.(type) {
+	case byte:
+		return int64(v), true
 	case int:
 		return int64(v), true
 	case int64:
 		return v, true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	case *big.Int:
+		if v.IsInt64() {
+			return v.Int64(), true
+		}
+	case untypedInt:
+		return int64(v), true
+	case untypedFloat:
+		if v == untypedFloat(math.Trunc(float64(v))) {
+			return int64(v), true
+		}
 	}
 	return 0, false
 }
 
+func toUint32(v interface{}) (uint32, bool) {
+	n, ok := toUint64(v)
+	if !ok || n > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case byte:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case *big.Int:
+		if v.IsUint64() {
+			return v.Uint64(), true
+		}
+		return 0, false
+	}
+	n, ok := toInt64(v)
+	if !ok || n < 0 {
+		return 0, false
+	}
+	return uint64(n), true
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case byte:
+		return big.NewInt(int64(v)), true
+	case int:
+		return big.NewInt(int64(v)), true
+	case int64:
+		return big.NewInt(v), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Int).SetUint64(v), true
+	case *big.Int:
+		if v == nil {
+			return new(big.Int), true
+		}
+		return new(big.Int).Set(v), true
+	case untypedInt:
+		return big.NewInt(int64(v)), true
+	case untypedFloat:
+		if v == untypedFloat(math.Trunc(float64(v))) && !math.IsInf(float64(v), 0) {
+			b, _ := big.NewFloat(float64(v)).Int(nil)
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func toDecimal(v interface{}) (Decimal, bool) {
+	switch v := v.(type) {
+	case Decimal:
+		return v, true
+	case float32:
+		return parseDecimal(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		return parseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case untypedFloat:
+		return parseDecimal(strconv.FormatFloat(float64(v), 'f', -1, 64))
+	}
+	b, ok := toBigInt(v)
+	if !ok {
+		return Decimal{}, false
+	}
+	return Decimal{Rat: new(big.Rat).SetInt(b)}, true
+}
+
+// bigResult returns b as an untyped constant if it fits in int64, and as a
+// bigint otherwise.
+func bigResult(b *big.Int) interface{} {
+	if b.IsInt64() {
+		return untypedInt(b.Int64())
+	}
+	return b
+}
+
 func toFloat32(v interface{}) (float32, bool) {
 	switch v := v.(type) {
+	case byte:
+		return float32(v), true
 	case int:
 		return float32(v), true
 	case int64:
 		return float32(v), true
+	case uint32:
+		return float32(v), true
+	case uint64:
+		return float32(v), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float32()
+		return f, true
+	case Decimal:
+		f, _ := v.rat().Float32()
+		return f, true
 	case float32:
 		return v, true
 	case float64:
 		return float32(v), true
+	case untypedInt:
+		return float32(v), true
+	case untypedFloat:
+		return float32(v), true
 	}
 	return 0, false
 }
 
 func toFloat64(v interface{}) (float64, bool) {
 	switch v := v.(type) {
+	case byte:
+		return float64(v), true
 	case int:
 		return float64(v), true
 	case int64:
 		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case Decimal:
+		f, _ := v.rat().Float64()
+		return f, true
 	case float32:
 		return float64(v), true
 	case float64:
 		return v, true
+	case untypedInt:
+		return float64(v), true
+	case untypedFloat:
+		return float64(v), true
 	}
 	return 0, false
 }
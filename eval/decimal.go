@@ -0,0 +1,117 @@
+package eval
+
+import (
+	"math/big"
+	"strings"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// Decimal is an exact decimal number. Scale is the number of digits after the
+// decimal point, as written in the input for a scanned number, or -1 for a
+// quotient whose decimal expansion does not terminate.
+type Decimal struct {
+	Rat   *big.Rat
+	Scale int
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.Rat == nil {
+		return new(big.Rat)
+	}
+	return d.Rat
+}
+
+func (d Decimal) String() string {
+	if d.Scale < 0 {
+		return d.rat().RatString()
+	}
+	return d.rat().FloatString(d.Scale)
+}
+
+// parseDecimal parses s, which must be an optionally signed number in plain
+// decimal notation, with digits on both sides of the decimal point if it has
+// one.
+func parseDecimal(s string) (Decimal, bool) {
+	t := strings.TrimLeft(s, "+-")
+	if len(s)-len(t) > 1 {
+		return Decimal{}, false
+	}
+	i, f, point := strings.Cut(t, ".")
+	if !isDigits(i) || point && !isDigits(f) {
+		return Decimal{}, false
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, false
+	}
+	return Decimal{Rat: r, Scale: len(f)}, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func decimalOp(pos lexer.Position, op ast.Operator, l, r Decimal) (interface{}, error) {
+	z := new(big.Rat)
+	switch op {
+	case "+":
+		return Decimal{Rat: z.Add(l.rat(), r.rat()), Scale: maxScale(l.Scale, r.Scale)}, nil
+	case "-":
+		return Decimal{Rat: z.Sub(l.rat(), r.rat()), Scale: maxScale(l.Scale, r.Scale)}, nil
+	case "*":
+		s := l.Scale + r.Scale
+		if l.Scale < 0 || r.Scale < 0 {
+			s = -1
+		}
+		return Decimal{Rat: z.Mul(l.rat(), r.rat()), Scale: s}, nil
+	case "/":
+		if r.rat().Sign() == 0 {
+			return nil, ErrDivisionByZero{Pos: pos}
+		}
+		z.Quo(l.rat(), r.rat())
+		return Decimal{Rat: z, Scale: terminatingScale(z)}, nil
+	}
+	return nil, ErrInvalidOperation{Pos: pos}
+}
+
+func maxScale(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return max(a, b)
+}
+
+// terminatingScale returns the number of digits after the decimal point of r,
+// or -1 if its decimal expansion does not terminate.
+func terminatingScale(r *big.Rat) int {
+	d := new(big.Int).Set(r.Denom())
+	twos := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	fives := 0
+	five, q, m := big.NewInt(5), new(big.Int), new(big.Int)
+	for {
+		q.QuoRem(d, five, m)
+		if m.Sign() != 0 {
+			break
+		}
+		d.Set(q)
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return -1
+	}
+	return max(twos, fives)
+}
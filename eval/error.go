@@ -2,6 +2,9 @@ package eval
 
 import (
 	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
 
 	"git.furqansoftware.net/toph/scanlib/ast"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -16,6 +19,33 @@ func (e ErrUndefined) Error() string {
 	return fmt.Sprintf("%d:%d: undefined: "+e.Name, e.Pos.Line, e.Pos.Column)
 }
 
+type ErrInvalidParam struct {
+	Name string
+}
+
+func (e ErrInvalidParam) Error() string {
+	return "invalid param " + e.Name
+}
+
+type ErrInvalidLetType struct {
+	Pos lexer.Position
+}
+
+func (e ErrInvalidLetType) Error() string {
+	return fmt.Sprintf("%d:%d: invalid let type", e.Pos.Line, e.Pos.Column)
+}
+
+type ErrOverflow struct {
+	Pos    lexer.Position
+	Cursor Cursor
+	Name   string
+	Type   string
+}
+
+func (e ErrOverflow) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: %s overflows %s", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col, e.Name, e.Type)
+}
+
 type ErrCantScanType struct{}
 
 func (e ErrCantScanType) Error() string {
@@ -28,6 +58,33 @@ func (e ErrInvalidArgument) Error() string {
 	return "invalid argument"
 }
 
+type ErrInvalidRegexp struct {
+	Pos lexer.Position
+	Err error
+}
+
+func (e ErrInvalidRegexp) Error() string {
+	return fmt.Sprintf("%d:%d: invalid regular expression: %v", e.Pos.Line, e.Pos.Column, e.Err)
+}
+
+type ErrInvalidSeparator struct {
+	Pos lexer.Position
+	Sep string
+}
+
+func (e ErrInvalidSeparator) Error() string {
+	return fmt.Sprintf("%d:%d: invalid separator %q", e.Pos.Line, e.Pos.Column, e.Sep)
+}
+
+type ErrInvalidWidth struct {
+	Pos   lexer.Position
+	Width interface{}
+}
+
+func (e ErrInvalidWidth) Error() string {
+	return fmt.Sprintf("%d:%d: invalid width %v", e.Pos.Line, e.Pos.Column, e.Width)
+}
+
 type ErrInvalidOperation struct {
 	Pos lexer.Position
 }
@@ -36,6 +93,14 @@ func (e ErrInvalidOperation) Error() string {
 	return fmt.Sprintf("%d:%d: invalid operation", e.Pos.Line, e.Pos.Column)
 }
 
+type ErrDivisionByZero struct {
+	Pos lexer.Position
+}
+
+func (e ErrDivisionByZero) Error() string {
+	return fmt.Sprintf("%d:%d: division by zero", e.Pos.Line, e.Pos.Column)
+}
+
 type ErrNonIntegerIndex struct {
 	Pos lexer.Position
 }
@@ -58,11 +123,42 @@ func (e ErrInvalidIndex) Error() string {
 	}
 }
 
+type ErrInvalidSlice struct {
+	Pos               lexer.Position
+	Low, High, Length int
+}
+
+func (e ErrInvalidSlice) Error() string {
+	return fmt.Sprintf("%d:%d: invalid slice indices [%d:%d] (out of bounds for length %d)", e.Pos.Line, e.Pos.Column, e.Low, e.High, e.Length)
+}
+
 type ErrCheckError struct {
 	Pos    lexer.Position
 	Cursor Cursor
 	Expr   *ast.Expr
 	Values Values
+	Bounds *Bounds
+
+	// Iterations holds the enclosing for loop iterations when the check
+	// belongs to a let declaration, or the iterations of the all quantifiers
+	// that failed, outermost first.
+	Iterations []Iteration
+
+	// Operands holds the indexed values that the innermost failed all
+	// quantifier was evaluated on.
+	Operands []Operand
+
+	// Subtask is the name of the subtask the check belongs to, if any.
+	Subtask string
+
+	// Edge is the edge that violates a graph property checked by a graph
+	// function, if any. Cursor then points at the edge in the input.
+	Edge *Edge
+
+	// Element is the first element that violates an array property checked
+	// by an array function, if any. Cursor then points at the element in the
+	// input.
+	Element *Element
 }
 
 func (e ErrCheckError) Error() string {
@@ -70,8 +166,10 @@ func (e ErrCheckError) Error() string {
 	varsseen := map[string]bool{}
 	ast.Inspect(e.Expr, func(n ast.Node) bool {
 		switch n := n.(type) {
+		case *ast.Quantifier:
+			varsseen[n.Index] = true
 		case *ast.Variable:
-			if varsseen[n.Ident] || len(n.Indices) > 0 {
+			if varsseen[n.Ident] || len(n.Indices) > 0 || e.Values[n.Ident].Kind() == reflect.Slice {
 				return true
 			}
 			varsseen[n.Ident] = true
@@ -79,10 +177,7 @@ func (e ErrCheckError) Error() string {
 		}
 		return len(vars) < 3
 	})
-	s := []byte{}
-	for _, t := range e.Expr.Tokens {
-		s = append(s, []byte(t.Value)...)
-	}
+	s := formatTokens(e.Expr.Tokens)
 	msg := fmt.Sprintf("%d:%d~%d:%d: check error %s", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col, ellipsize(s, 20))
 	if len(vars) > 0 {
 		msg += " ("
@@ -90,13 +185,121 @@ func (e ErrCheckError) Error() string {
 			if i > 0 {
 				msg += ", "
 			}
-			msg += fmt.Sprintf("%s=%#v", k, e.Values[k].Elem().Interface())
+			v := e.Values[k]
+			if v.Kind() == reflect.Ptr {
+				v = v.Elem()
+			}
+			msg += fmt.Sprintf("%s=%s", k, formatValue(defaultType(v.Interface())))
 		}
 		msg += ")"
 	}
+	if e.Bounds != nil {
+		msg += " not in " + e.Bounds.String()
+	}
+	for i, it := range e.Iterations {
+		if i == 0 {
+			msg += " at "
+		} else {
+			msg += ", "
+		}
+		msg += fmt.Sprintf("%s=%d", it.Index, it.Value)
+	}
+	if len(e.Operands) > 0 {
+		msg += " ("
+		for i, o := range e.Operands {
+			if i > 0 {
+				msg += ", "
+			}
+			msg += fmt.Sprintf("%s=%s", formatTokens(o.Tokens), formatValue(o.Value))
+		}
+		msg += ")"
+	}
+	if e.Edge != nil {
+		msg += fmt.Sprintf(" at edge %d (%d, %d)", e.Edge.Index, e.Edge.U, e.Edge.V)
+	}
+	if e.Element != nil {
+		msg += fmt.Sprintf(" at %s[%d]=%s", e.Element.Array, e.Element.Index, formatValue(e.Element.Value))
+	}
+	if e.Subtask != "" {
+		msg += fmt.Sprintf(" in subtask %q", e.Subtask)
+	}
 	return msg
 }
 
+// Iteration is an iteration of a for range loop or a quantifier.
+type Iteration struct {
+	Index string
+	Value int
+}
+
+// Operand is the value of an indexed variable in a failed check.
+type Operand struct {
+	Tokens []lexer.Token
+	Value  interface{}
+}
+
+// formatTokens spells out the source tokens of an expression.
+func formatTokens(tokens []lexer.Token) []byte {
+	s := []byte{}
+	for _, t := range tokens {
+		switch {
+		case wordOperators[t.Value]:
+			s = append(s, ' ')
+			s = append(s, []byte(t.Value)...)
+			s = append(s, ' ')
+			continue
+		case len(s) > 0 && t.Value != "" && isWordByte(s[len(s)-1]) && isWordByte(t.Value[0]):
+			s = append(s, ' ')
+		}
+		s = append(s, []byte(t.Value)...)
+	}
+	return s
+}
+
+// formatValue formats v as Go source, except that characters are quoted as
+// character literals rather than printed as bytes, and unsigned integers are
+// printed in decimal.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case byte:
+		return strconv.QuoteRune(rune(v))
+	case uint32, uint64:
+		return fmt.Sprint(v)
+	case *big.Int:
+		if v == nil {
+			return "0"
+		}
+		return v.String()
+	case Decimal:
+		return v.String()
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+// Bounds is the range of values a check accepts.
+type Bounds struct {
+	Low, High             interface{}
+	LowClosed, HighClosed bool
+}
+
+func (b Bounds) String() string {
+	l, h := "(", ")"
+	if b.LowClosed {
+		l = "["
+	}
+	if b.HighClosed {
+		h = "]"
+	}
+	lo, hi := fmt.Sprint(b.Low), fmt.Sprint(b.High)
+	if c, ok := b.Low.(byte); ok {
+		lo = strconv.QuoteRune(rune(c))
+	}
+	if c, ok := b.High.(byte); ok {
+		hi = strconv.QuoteRune(rune(c))
+	}
+	return fmt.Sprintf("%s%s, %s%s", l, lo, hi, h)
+}
+
 type ErrExpectedEOL struct {
 	Pos    lexer.Position
 	Got    []byte
@@ -138,16 +341,78 @@ type ErrBadParse struct {
 	Want   string
 	Got    []byte
 	Cursor Cursor
+	Reason string
 }
 
 func (e ErrBadParse) Error() string {
-	return fmt.Sprintf("%d:%d~%d:%d: parse error: want %s, got %q", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col, e.Want, e.Got)
+	s := fmt.Sprintf("%d:%d~%d:%d: parse error: want %s, got %q", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col, e.Want, e.Got)
+	if e.Reason != "" {
+		s += " (" + e.Reason + ")"
+	}
+	return s
+}
+
+type ErrWhitespace struct {
+	Pos    lexer.Position
+	Got    []byte
+	Cursor Cursor
+	Reason string
+}
+
+func (e ErrWhitespace) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: whitespace error: %s, got %q", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col, e.Reason, e.Got)
+}
+
+type ErrCRLF struct {
+	Pos    lexer.Position
+	Cursor Cursor
+}
+
+func (e ErrCRLF) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: line ending error: CRLF, want LF", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col)
+}
+
+type ErrLoneCR struct {
+	Pos    lexer.Position
+	Cursor Cursor
+}
+
+func (e ErrLoneCR) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: line ending error: CR, want LF", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col)
+}
+
+type ErrBOM struct {
+	Pos    lexer.Position
+	Cursor Cursor
+}
+
+func (e ErrBOM) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: encoding error: byte order mark", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col)
+}
+
+type ErrNoFinalNewline struct {
+	Pos    lexer.Position
+	Cursor Cursor
+}
+
+func (e ErrNoFinalNewline) Error() string {
+	return fmt.Sprintf("%d:%d~%d:%d: line ending error: no newline at end of input", e.Pos.Line, e.Pos.Column, e.Cursor.Ln, e.Cursor.Col)
 }
 
 type Cursor struct {
 	Ln, Col int
 }
 
+// wordOperators are spelled out when a check expression is printed back.
+var wordOperators = map[string]bool{
+	"in":  true,
+	"xor": true,
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
 func ellipsize(b []byte, n int) string {
 	r := []rune(string(b))
 	if len(r) > n {
@@ -4,47 +4,66 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
+	"regexp"
 
 	"git.furqansoftware.net/toph/scanlib/ast"
 	"github.com/alecthomas/participle/v2/lexer"
 )
 
 type evaluator struct {
-	Source *ast.Source
-	Input  *Input
-	Values Values
+	program *Program
+	Input   *Input
+	Values  Values
+
+	params     map[string]interface{}
+	reducers   map[string][]*reducer
+	iterations []Iteration
+
+	subtask  string
+	subtasks map[string]bool
+	report   SubtaskReport
+
+	// Cursors of the scanned elements of arrays passed to graph and array
+	// functions, by element address.
+	cursors map[uintptr]Cursor
+
+	// The regular expressions given to re that were not compiled with the
+	// program, by pattern.
+	regexps map[string]*regexp.Regexp
+
+	// The edge or element that a graph or array function found to violate a
+	// property, and where it was scanned.
+	edge    *Edge
+	element *Element
+	witness *Cursor
+
+	// The iterations and operands of the all quantifiers that failed.
+	quantified []Iteration
+	operands   []Operand
 }
 
-func Evaluate(source *ast.Source, input io.Reader, options ...Option) (values Values, err error) {
-	e := evaluator{
-		Source: source,
-		Values: Values{},
-	}
-	e.Input, err = newInput(input)
+// reducer folds the values scanned into a let declaration's source variable
+// into the let variable.
+type reducer struct {
+	Pos    lexer.Position
+	Name   string
+	Type   string
+	Reduce Reducer
+	Check  []ast.Expr
+	Value  reflect.Value
+	N      int
+}
+
+// Evaluate compiles source and runs it on input. To evaluate a scanspec on
+// many inputs, Compile it once instead.
+func Evaluate(source *ast.Source, input io.Reader, options ...Option) (Values, error) {
+	p, err := Compile(source)
 	if err != nil {
 		return nil, err
 	}
-
-	for _, o := range options {
-		o.apply(&e)
-	}
-
-	defer func() {
-		v := recover()
-		if v == nil {
-			return
-		}
-		switch v := v.(type) {
-		case error:
-			err = v
-		default:
-			panic(err)
-		}
-	}()
-
-	ast.Walk(&e, e.Source)
-	return e.Values, nil
+	return p.Run(input, options...)
 }
 
 func (e *evaluator) Visit(n ast.Node) (w ast.Visitor) {
@@ -67,6 +86,16 @@ func (e *evaluator) Visit(n ast.Node) (w ast.Visitor) {
 		catch(err)
 		return nil
 
+	case *ast.ConstDecl:
+		err := e.constDecl(n)
+		catch(err)
+		return nil
+
+	case *ast.LetDecl:
+		err := e.letDecl(n)
+		catch(err)
+		return nil
+
 	case *ast.ScanStmt:
 		err := e.scanStmt(n)
 		catch(err)
@@ -92,6 +121,10 @@ func (e *evaluator) Visit(n ast.Node) (w ast.Visitor) {
 		catch(err)
 		return nil
 
+	case *ast.SubtaskStmt:
+		e.subtaskStmt(n)
+		return nil
+
 	case *ast.EOLStmt:
 		err := e.eolStmt(n)
 		catch(err)
@@ -113,76 +146,189 @@ func (e *evaluator) varDecl(n *ast.VarDecl) error {
 			e.Values[x] = reflect.New(Types[*n.VarSpec.Type.TypeName])
 
 		case n.VarSpec.Type.TypeLit != nil:
-			l, err := e.expr(&n.VarSpec.Type.TypeLit.ArrayType.ArrayLength)
+			v, err := e.makeArray(n.VarSpec.Type.TypeLit.ArrayType)
 			if err != nil {
 				return err
 			}
-			li, ok := l.(int)
-			if !ok {
-				return errors.New("invalid array bound")
-			}
-			// XXX(hjr265): This work's for one dimensional arrays only.
-			t := reflect.SliceOf(Types[*n.VarSpec.Type.TypeLit.ArrayType.ElementType.TypeName])
-			v := reflect.MakeSlice(t, li, li)
 			e.Values[x] = v
 		}
 	}
 	return nil
 }
 
-func (e *evaluator) scanStmt(n *ast.ScanStmt) error {
-	for _, f := range n.RefList {
-		v, ok := e.Values[f.Ident]
+func (e *evaluator) constDecl(n *ast.ConstDecl) error {
+	if _, ok := e.params[n.ConstSpec.Ident]; ok {
+		// Supplied with Params, which takes precedence over the spec.
+		return nil
+	}
+	v, err := e.expr(&n.ConstSpec.Value)
+	if err != nil {
+		return err
+	}
+	e.Values[n.ConstSpec.Ident] = constant(v)
+	return nil
+}
+
+func (e *evaluator) letDecl(n *ast.LetDecl) error {
+	if n.LetSpec.Type.TypeName == nil {
+		return ErrInvalidLetType{Pos: n.Pos}
+	}
+	t := *n.LetSpec.Type.TypeName
+	fn, ok := Reducers[n.LetSpec.Reducer]
+	if !ok {
+		return ErrUndefined{Pos: n.Pos, Name: n.LetSpec.Reducer}
+	}
+	for _, x := range n.LetSpec.IdentList {
+		v := reflect.New(Types[t])
+		e.Values[x] = v
+
+		// Declaring a let variable again starts it afresh.
+		rs := e.reducers[n.LetSpec.Source]
+		for i := 0; i < len(rs); i++ {
+			if rs[i].Name == x {
+				rs = append(rs[:i], rs[i+1:]...)
+				i--
+			}
+		}
+		e.reducers[n.LetSpec.Source] = append(rs, &reducer{
+			Pos:    n.Pos,
+			Name:   x,
+			Type:   t,
+			Reduce: fn,
+			Check:  n.LetSpec.Check,
+			Value:  v,
+		})
+	}
+	return nil
+}
+
+// reduce folds v, just scanned into the variable source, into the let
+// variables derived from it.
+func (e *evaluator) reduce(pos lexer.Position, source string, v interface{}) error {
+	for _, r := range e.reducers[source] {
+		x, err := r.Reduce(r.Value.Elem().Interface(), v, r.N)
+		if err != nil {
+			return ErrInvalidOperation{Pos: pos}
+		}
+		r.N++
+		x, ok := narrow(x, r.Type)
 		if !ok {
-			return ErrUndefined{Pos: n.Pos, Name: f.Ident}
+			return ErrOverflow{Pos: pos, Cursor: e.Input.cur, Name: r.Name, Type: r.Type}
 		}
-		for _, i := range f.Indices {
-			r, err := e.expr(&i)
+		r.Value.Elem().Set(reflect.ValueOf(x))
+
+		for i := range r.Check {
+			x := &r.Check[i]
+			v, err := e.expr(x)
 			if err != nil {
 				return err
 			}
-			ri, ok := r.(int)
-			if !ok {
-				return ErrNonIntegerIndex{Pos: i.Pos}
-			}
-			l := v.Len()
-			if ri < 0 || ri >= l {
-				return ErrInvalidIndex{Pos: i.Pos, Index: ri, Length: l}
+			vb, _ := toBool(v)
+			if !vb {
+				return ErrCheckError{Pos: r.Pos, Cursor: e.Input.cur, Expr: x, Values: e.Values, Bounds: e.bounds(x), Iterations: append([]Iteration{}, e.iterations...)}
 			}
-			v = v.Index(ri)
+		}
+	}
+	return nil
+}
+
+// makeArray allocates a (possibly nested) slice for the array type n. All
+// array bounds are evaluated once, before any allocation takes place.
+func (e *evaluator) makeArray(n *ast.ArrayType) (reflect.Value, error) {
+	lengths, t := n.Dims()
+	dims := []int{}
+	for _, x := range lengths {
+		l, err := e.expr(x)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		li, ok := toInt(l)
+		if !ok || li < 0 {
+			return reflect.Value{}, errors.New("invalid array bound")
+		}
+		dims = append(dims, li)
+	}
+	et := Types[t]
+	for range dims {
+		et = reflect.SliceOf(et)
+	}
+	return makeSlice(et, dims), nil
+}
+
+func makeSlice(t reflect.Type, dims []int) reflect.Value {
+	v := reflect.MakeSlice(t, dims[0], dims[0])
+	if len(dims) > 1 {
+		for i := 0; i < dims[0]; i++ {
+			v.Index(i).Set(makeSlice(t.Elem(), dims[1:]))
+		}
+	}
+	return v
+}
+
+// index resolves the element of v addressed by indices, checking that each
+// index is an in-bounds integer.
+func (e *evaluator) index(v reflect.Value, indices []ast.Expr) (reflect.Value, error) {
+	for k := range indices {
+		i := &indices[k]
+		r, err := e.expr(i)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ri, ok := toInt(r)
+		if !ok {
+			return reflect.Value{}, ErrNonIntegerIndex{Pos: i.Pos}
 		}
 		if v.Kind() == reflect.Ptr {
 			v = v.Elem()
 		}
-		var err error
-		switch v.Type() {
-		case Types["bool"]:
-			var d bool
-			d, err = e.Input.readBool()
-			v.SetBool(d)
-		case Types["int"]:
-			var d int
-			d, err = e.Input.readInt()
-			v.SetInt(int64(d))
-		case Types["int64"]:
-			var d int64
-			d, err = e.Input.readInt64()
-			v.SetInt(d)
-		case Types["float32"]:
-			var d float32
-			d, err = e.Input.readFloat32()
-			v.SetFloat(float64(d))
-		case Types["float64"]:
-			var d float64
-			d, err = e.Input.readFloat64()
-			v.SetFloat(d)
-		case Types["string"]:
-			var d string
-			d, err = e.Input.readString()
-			v.SetString(d)
-		default:
-			return ErrCantScanType{}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.String {
+			return reflect.Value{}, ErrInvalidOperation{Pos: i.Pos}
 		}
+		l := v.Len()
+		if ri < 0 || ri >= l {
+			return reflect.Value{}, ErrInvalidIndex{Pos: i.Pos, Index: ri, Length: l}
+		}
+		v = v.Index(ri)
+	}
+	return v, nil
+}
+
+// slice slices the string or array v from low up to high.
+func (e *evaluator) slice(v reflect.Value, low, high *ast.Expr) (reflect.Value, error) {
+	bounds := [2]int{}
+	for j, x := range []*ast.Expr{low, high} {
+		r, err := e.expr(x)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ri, ok := toInt(r)
+		if !ok {
+			return reflect.Value{}, ErrNonIntegerIndex{Pos: x.Pos}
+		}
+		bounds[j] = ri
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.String {
+		return reflect.Value{}, ErrInvalidOperation{Pos: low.Pos}
+	}
+	l, h := bounds[0], bounds[1]
+	if l < 0 || l > h || h > v.Len() {
+		return reflect.Value{}, ErrInvalidSlice{Pos: low.Pos, Low: l, High: h, Length: v.Len()}
+	}
+	return v.Slice(l, h), nil
+}
+
+func (e *evaluator) scanStmt(n *ast.ScanStmt) error {
+	if n.Format != "" {
+		defer func(strict bool) {
+			e.Input.strictNumbers = strict
+		}(e.Input.strictNumbers)
+		e.Input.strictNumbers = n.Format == "strict"
+	}
+	if n.Sep != "" || n.Width != nil {
+		err := e.splitToken(n)
 		if err != nil {
 			if err == io.EOF {
 				return ErrUnexpectedEOF{Pos: n.Pos}
@@ -190,6 +336,179 @@ func (e *evaluator) scanStmt(n *ast.ScanStmt) error {
 			return e.enrichError(err, n.Pos)
 		}
 	}
+	for _, f := range n.RefList {
+		v, err := e.reference(n.Pos, &f)
+		if err != nil {
+			return err
+		}
+		if f.High == nil {
+			err = e.scanValue(n.Pos, f.Ident, v, len(f.Indices) > 0)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for j := 0; j < v.Len(); j++ {
+			err = e.scanValue(n.Pos, f.Ident, v.Index(j), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reference returns the variable, the array element or the slice of an array
+// that f refers to.
+func (e *evaluator) reference(pos lexer.Position, f *ast.Reference) (reflect.Value, error) {
+	v, ok := e.Values[f.Ident]
+	if !ok {
+		return reflect.Value{}, ErrUndefined{Pos: pos, Name: f.Ident}
+	}
+	if f.High == nil {
+		return e.index(v, f.Indices)
+	}
+	k := len(f.Indices) - 1
+	v, err := e.index(v, f.Indices[:k])
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, ErrInvalidOperation{Pos: f.Indices[k].Pos}
+	}
+	return e.slice(v, &f.Indices[k], f.High)
+}
+
+// splitToken reads the next token and splits it into the fields of the values
+// n scans, by the separator or the width of n.
+func (e *evaluator) splitToken(n *ast.ScanStmt) error {
+	c := 0
+	for _, f := range n.RefList {
+		if f.High == nil {
+			c++
+			continue
+		}
+		v, err := e.reference(n.Pos, &f)
+		if err != nil {
+			return err
+		}
+		c += v.Len()
+	}
+	if c == 0 {
+		return nil
+	}
+
+	if n.Sep != "" {
+		return e.Input.split(n.Sep, c)
+	}
+	w, err := e.expr(n.Width)
+	if err != nil {
+		return err
+	}
+	wi, ok := toInt(w)
+	if !ok || wi < 1 {
+		return ErrInvalidWidth{Pos: n.Width.Pos, Width: w}
+	}
+	return e.Input.splitWidth(wi, c)
+}
+
+// scanValue scans the variable or array element v of the variable named
+// ident.
+func (e *evaluator) scanValue(pos lexer.Position, ident string, v reflect.Value, element bool) error {
+	if v.Kind() == reflect.Ptr && v.Type() != Types["bigint"] {
+		v = v.Elem()
+	}
+	if !v.CanSet() {
+		return ErrCantScanType{}
+	}
+	var err error
+	switch v.Type() {
+	case Types["bool"]:
+		var d bool
+		d, err = e.Input.readBool()
+		v.SetBool(d)
+	case Types["int"]:
+		var d int
+		d, err = e.Input.readInt()
+		v.SetInt(int64(d))
+	case Types["int64"]:
+		var d int64
+		d, err = e.Input.readInt64()
+		v.SetInt(d)
+	case Types["char"]:
+		var d byte
+		d, err = e.Input.readChar()
+		v.SetUint(uint64(d))
+	case reflect.SliceOf(Types["char"]):
+		var d []byte
+		d, err = e.Input.readChars(v.Len())
+		reflect.Copy(v, reflect.ValueOf(d))
+	case Types["uint32"]:
+		var d uint32
+		d, err = e.Input.readUint32()
+		v.SetUint(uint64(d))
+	case Types["uint64"]:
+		var d uint64
+		d, err = e.Input.readUint64()
+		v.SetUint(d)
+	case Types["decimal"]:
+		var d Decimal
+		d, err = e.Input.readDecimal()
+		v.Set(reflect.ValueOf(d))
+	case Types["bigint"]:
+		var d *big.Int
+		d, err = e.Input.readBigInt()
+		v.Set(reflect.ValueOf(d))
+	case Types["float32"]:
+		var d float32
+		d, err = e.Input.readFloat32()
+		v.SetFloat(float64(d))
+	case Types["float64"]:
+		var d float64
+		d, err = e.Input.readFloat64()
+		v.SetFloat(d)
+	case Types["string"]:
+		var d string
+		d, err = e.Input.readString()
+		v.SetString(d)
+	default:
+		return ErrCantScanType{}
+	}
+	if err != nil {
+		if err == io.EOF {
+			return ErrUnexpectedEOF{Pos: pos}
+		}
+		return e.enrichError(err, pos)
+	}
+	if v.Kind() == reflect.Slice {
+		return e.scannedChars(pos, ident, v)
+	}
+	err = e.reduce(pos, ident, v.Interface())
+	if err != nil {
+		return err
+	}
+	if e.program.tracked[ident] && element {
+		e.cursors[v.Addr().Pointer()] = e.Input.cur
+	}
+	return nil
+}
+
+// scannedChars folds the characters just scanned into the row v of the
+// variable named ident as individual values, each scanned at its own column.
+func (e *evaluator) scannedChars(pos lexer.Position, ident string, v reflect.Value) error {
+	cur := e.Input.cur
+	for j := 0; j < v.Len(); j++ {
+		err := e.reduce(pos, ident, v.Index(j).Interface())
+		if err != nil {
+			return err
+		}
+		if e.program.tracked[ident] {
+			e.cursors[v.Index(j).Addr().Pointer()] = Cursor{cur.Ln, cur.Col + j}
+		}
+	}
 	return nil
 }
 
@@ -199,25 +518,13 @@ func (e *evaluator) scanlnStmt(n *ast.ScanlnStmt) error {
 		if !ok {
 			return ErrUndefined{Pos: n.Pos, Name: f.Ident}
 		}
-		for _, i := range f.Indices {
-			r, err := e.expr(&i)
-			if err != nil {
-				return err
-			}
-			ri, ok := r.(int)
-			if !ok {
-				return ErrNonIntegerIndex{Pos: i.Pos}
-			}
-			l := v.Len()
-			if ri < 0 || ri >= l {
-				return ErrInvalidIndex{Pos: i.Pos, Index: ri, Length: l}
-			}
-			v = v.Index(ri)
+		v, err := e.index(v, f.Indices)
+		if err != nil {
+			return err
 		}
 		if v.Kind() == reflect.Ptr {
 			v = v.Elem()
 		}
-		var err error
 		switch v.Type() {
 		case Types["string"]:
 			var d string
@@ -232,19 +539,104 @@ func (e *evaluator) scanlnStmt(n *ast.ScanlnStmt) error {
 			}
 			return e.enrichError(err, n.Pos)
 		}
+		err = e.reduce(n.Pos, f.Ident, v.Interface())
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// trackedArgs returns the names of the variables passed to graph and array
+// functions in source.
+func trackedArgs(source *ast.Source) map[string]bool {
+	names := map[string]bool{}
+	ast.Inspect(source, func(n ast.Node) bool {
+		c, ok := n.(*ast.CallExpr)
+		if !ok || (GraphFunctions[c.Ident] == nil && ArrayFunctions[c.Ident] == nil) {
+			return true
+		}
+		for i := range c.Args {
+			ast.Inspect(&c.Args[i], func(n ast.Node) bool {
+				if n, ok := n.(*ast.Variable); ok {
+					names[n.Ident] = true
+				}
+				return true
+			})
+		}
+		return true
+	})
+	return names
+}
+
 func (e *evaluator) checkStmt(n *ast.CheckStmt) error {
-	for _, x := range n.ExprList {
-		v, err := e.expr(&x)
+	if e.subtask != "" && e.report[e.subtask] != nil {
+		// The input has failed this subtask already.
+		return nil
+	}
+	for i := range n.ExprList {
+		x := &n.ExprList[i]
+		e.edge, e.element, e.witness = nil, nil, nil
+		e.quantified, e.operands = nil, nil
+		v, err := e.expr(x)
 		if err != nil {
 			return err
 		}
-		vb, _ := v.(bool)
+		vb, _ := toBool(v)
 		if !vb {
-			return ErrCheckError{Pos: n.Pos, Cursor: e.Input.cur, Expr: &x, Values: e.Values}
+			err := ErrCheckError{Pos: n.Pos, Cursor: e.Input.cur, Expr: x, Values: e.Values, Bounds: e.bounds(x), Subtask: e.subtask, Edge: e.edge, Element: e.element, Iterations: e.quantified, Operands: e.operands}
+			if e.witness != nil {
+				err.Cursor = *e.witness
+			}
+			if e.subtask != "" {
+				if !e.subtasks[e.subtask] {
+					// Only reported, so evaluation goes on.
+					err.Values = e.Values.snapshot()
+					e.report[e.subtask] = err
+					return nil
+				}
+				e.report[e.subtask] = err
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// bounds returns the range checked by x when x is a single chained comparison
+// (lo <= v < hi) or a membership test (v in [lo, hi)), and nil otherwise.
+func (e *evaluator) bounds(x *ast.Expr) *Bounds {
+	if len(x.Right) > 0 || len(x.Left.Right) > 0 {
+		return nil
+	}
+	n := x.Left.Left
+	switch {
+	case n.Interval != nil:
+		lo, err := e.expr(&n.Interval.Low)
+		if err != nil {
+			return nil
+		}
+		hi, err := e.expr(&n.Interval.High)
+		if err != nil {
+			return nil
+		}
+		return &Bounds{Low: lo, High: hi, LowClosed: n.Interval.LowClosed, HighClosed: n.Interval.HighClosed}
+
+	case len(n.Right) == 2:
+		op0, op1 := n.Right[0].Operator, n.Right[1].Operator
+		l, err := e.relative(n.Left)
+		if err != nil {
+			return nil
+		}
+		r, err := e.relative(n.Right[1].Relative)
+		if err != nil {
+			return nil
+		}
+		switch {
+		case (op0 == "<" || op0 == "<=") && (op1 == "<" || op1 == "<="):
+			return &Bounds{Low: l, High: r, LowClosed: op0 == "<=", HighClosed: op1 == "<="}
+		case (op0 == ">" || op0 == ">=") && (op1 == ">" || op1 == ">="):
+			return &Bounds{Low: r, High: l, LowClosed: op1 == ">=", HighClosed: op0 == ">="}
 		}
 	}
 	return nil
@@ -303,8 +695,11 @@ func (e *evaluator) forStmtRange(n *ast.ForStmt) error {
 	if !ok {
 		return errors.New("invalid loop bound")
 	}
+	e.iterations = append(e.iterations, Iteration{Index: n.Range.Index})
+	defer func() { e.iterations = e.iterations[:len(e.iterations)-1] }()
 	for i := li; i < hi; i++ {
 		e.Values[n.Range.Index] = reflect.ValueOf(&i)
+		e.iterations[len(e.iterations)-1].Value = i
 		ast.Walk(e, &n.Block)
 	}
 	return nil
@@ -338,10 +733,20 @@ func (e *evaluator) forStmtlnScan(n *ast.ForStmt) error {
 	return nil
 }
 
+func (e *evaluator) subtaskStmt(n *ast.SubtaskStmt) {
+	if _, ok := e.report[n.Name]; !ok {
+		e.report[n.Name] = nil
+	}
+	outer := e.subtask
+	defer func() { e.subtask = outer }()
+	e.subtask = n.Name
+	ast.Walk(e, &n.Block)
+}
+
 func (e *evaluator) eolStmt(n *ast.EOLStmt) error {
 	eol, err := e.Input.isAtEOL()
 	if err != nil {
-		return err
+		return e.enrichError(err, n.Pos)
 	}
 	if !eol {
 		return ErrExpectedEOL{Pos: n.Pos, Got: e.Input.token, Cursor: e.Input.cur}
@@ -352,7 +757,7 @@ func (e *evaluator) eolStmt(n *ast.EOLStmt) error {
 func (e *evaluator) eofStmt(n *ast.EOFStmt) error {
 	eof, err := e.Input.isAtEOF()
 	if err != nil {
-		return err
+		return e.enrichError(err, n.Pos)
 	}
 	if !eof {
 		return ErrExpectedEOF{Pos: n.Pos, Got: e.Input.token}
@@ -361,6 +766,9 @@ func (e *evaluator) eofStmt(n *ast.EOFStmt) error {
 }
 
 func (e *evaluator) expr(n *ast.Expr) (interface{}, error) {
+	if v, ok := e.program.folded[n]; ok {
+		return v, nil
+	}
 	l, err := e.logicalOr(n.Left)
 	if err != nil {
 		return nil, err
@@ -411,14 +819,54 @@ func (e *evaluator) logicalAnd(n *ast.LogicalAnd) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if n.Interval != nil {
+		return e.interval(n.Interval, l)
+	}
+	if len(n.Right) == 0 {
+		return l, nil
+	}
+	// Comparisons chain: a < b <= c holds if both a < b and b <= c hold.
+	v := true
 	for _, c := range n.Right {
-		r, err := e.opRelative(c, l)
+		r, err := e.relative(c.Relative)
 		if err != nil {
 			return nil, err
 		}
+		b, err := compare(c.Pos, c.Operator, l, r)
+		if err != nil {
+			return nil, err
+		}
+		v = v && b
 		l = r
 	}
-	return l, nil
+	return v, nil
+}
+
+func (e *evaluator) interval(n *ast.Interval, v interface{}) (interface{}, error) {
+	lo, err := e.expr(&n.Low)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := e.expr(&n.High)
+	if err != nil {
+		return nil, err
+	}
+	var lop, hop ast.Operator = ">", "<"
+	if n.LowClosed {
+		lop = ">="
+	}
+	if n.HighClosed {
+		hop = "<="
+	}
+	lb, err := compare(n.Pos, lop, v, lo)
+	if err != nil {
+		return nil, err
+	}
+	hb, err := compare(n.Pos, hop, v, hi)
+	if err != nil {
+		return nil, err
+	}
+	return lb && hb, nil
 }
 
 func (e *evaluator) opLogicalAnd(n *ast.OpLogicalAnd, l interface{}) (interface{}, error) {
@@ -438,6 +886,9 @@ func (e *evaluator) opLogicalAnd(n *ast.OpLogicalAnd, l interface{}) (interface{
 }
 
 func (e *evaluator) relative(n *ast.Relative) (interface{}, error) {
+	if v, ok := e.program.folded[n]; ok {
+		return v, nil
+	}
 	l, err := e.addition(n.Left)
 	if err != nil {
 		return nil, err
@@ -452,120 +903,10 @@ func (e *evaluator) relative(n *ast.Relative) (interface{}, error) {
 	return l, nil
 }
 
-func (e *evaluator) opRelative(n *ast.OpRelative, l interface{}) (interface{}, error) {
-	r, err := e.relative(n.Relative)
-	if err != nil {
-		return nil, err
-	}
-	switch l := l.(type) {
-	case bool:
-		ri, ok := toBool(r)
-		if !ok {
-			return nil, ErrInvalidOperation{Pos: n.Pos}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		}
-
-	case int:
-		ri, ok := toInt(r)
-		if !ok {
-			return nil, ErrInvalidOperation{Pos: n.Pos}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		case "<=":
-			return l <= ri, nil
-		case ">=":
-			return l >= ri, nil
-		case "<":
-			return l < ri, nil
-		case ">":
-			return l > ri, nil
-		}
-
-	case int64:
-		ri, ok := toInt64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		case "<=":
-			return l <= ri, nil
-		case ">=":
-			return l >= ri, nil
-		case "<":
-			return l < ri, nil
-		case ">":
-			return l > ri, nil
-		}
-
-	case float32:
-		ri, ok := toFloat32(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		case "<=":
-			return l <= ri, nil
-		case ">=":
-			return l >= ri, nil
-		case "<":
-			return l < ri, nil
-		case ">":
-			return l > ri, nil
-		}
-
-	case float64:
-		ri, ok := toFloat64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		case "<=":
-			return l <= ri, nil
-		case ">=":
-			return l >= ri, nil
-		case "<":
-			return l < ri, nil
-		case ">":
-			return l > ri, nil
-		}
-
-	case string:
-		ri, ok := toString(r)
-		if !ok {
-			return nil, ErrInvalidOperation{Pos: n.Pos}
-		}
-		switch n.Operator {
-		case "==":
-			return l == ri, nil
-		case "!=":
-			return l != ri, nil
-		}
-	}
-	return nil, ErrInvalidOperation{}
-}
-
 func (e *evaluator) addition(n *ast.Addition) (interface{}, error) {
+	if v, ok := e.program.folded[n]; ok {
+		return v, nil
+	}
 	l, err := e.multiplication(n.Left)
 	if err != nil {
 		return nil, err
@@ -585,60 +926,29 @@ func (e *evaluator) opAddition(n *ast.OpAddition, l interface{}) (interface{}, e
 	if err != nil {
 		return nil, err
 	}
-	switch l := l.(type) {
-	case int:
-		ri, ok := toInt(r)
-		if !ok {
-			return nil, ErrInvalidOperation{Pos: n.Pos}
-		}
-		switch n.Operator {
-		case "+":
-			return l + ri, nil
-		case "-":
-			return l - ri, nil
-		}
-
-	case int64:
-		ri, ok := toInt64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "+":
-			return l + ri, nil
-		case "-":
-			return l - ri, nil
-		}
-
-	case float32:
-		ri, ok := toFloat32(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "+":
-			return l + ri, nil
-		case "-":
-			return l - ri, nil
-		}
-
-	case float64:
-		ri, ok := toFloat64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "+":
-			return l + ri, nil
-		case "-":
-			return l - ri, nil
-		}
-	}
-	return nil, ErrInvalidOperation{}
+	return binaryOp(n.Pos, n.Operator, l, r)
 }
 
 func (e *evaluator) multiplication(n *ast.Multiplication) (interface{}, error) {
-	return e.unary(n.Unary)
+	if v, ok := e.program.folded[n]; ok {
+		return v, nil
+	}
+	l, err := e.unary(n.Unary)
+	if err != nil {
+		return nil, err
+	}
+	if n.Exponent == nil {
+		return l, nil
+	}
+	r, err := e.primary(n.Exponent)
+	if err != nil {
+		return nil, err
+	}
+	v, err := pow(l, r)
+	if err != nil {
+		return nil, ErrInvalidOperation{Pos: n.Exponent.Pos}
+	}
+	return v, nil
 }
 
 func (e *evaluator) opMultiplication(n *ast.OpMultiplication, l interface{}) (interface{}, error) {
@@ -646,56 +956,7 @@ func (e *evaluator) opMultiplication(n *ast.OpMultiplication, l interface{}) (in
 	if err != nil {
 		return nil, err
 	}
-	switch l := l.(type) {
-	case int:
-		ri, ok := toInt(r)
-		if !ok {
-			return nil, ErrInvalidOperation{Pos: n.Pos}
-		}
-		switch n.Operator {
-		case "*":
-			return l * ri, nil
-		case "/":
-			return l / ri, nil
-		}
-
-	case int64:
-		ri, ok := toInt64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "*":
-			return l * ri, nil
-		case "/":
-			return l / ri, nil
-		}
-
-	case float32:
-		ri, ok := toFloat32(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "*":
-			return l * ri, nil
-		case "/":
-			return l / ri, nil
-		}
-
-	case float64:
-		ri, ok := toFloat64(r)
-		if !ok {
-			return nil, ErrInvalidOperation{}
-		}
-		switch n.Operator {
-		case "*":
-			return l * ri, nil
-		case "/":
-			return l / ri, nil
-		}
-	}
-	return nil, ErrInvalidOperation{}
+	return binaryOp(n.Pos, n.Operator, l, r)
 }
 
 func (e *evaluator) unary(n *ast.Unary) (interface{}, error) {
@@ -713,48 +974,105 @@ func (e *evaluator) unary(n *ast.Unary) (interface{}, error) {
 			return -v, nil
 		case int64:
 			return -v, nil
+		case uint32:
+			return -v, nil
+		case uint64:
+			return -v, nil
+		case *big.Int:
+			return new(big.Int).Neg(v), nil
+		case Decimal:
+			return Decimal{Rat: new(big.Rat).Neg(v.rat()), Scale: v.Scale}, nil
 		case float32:
 			return -v, nil
 		case float64:
 			return -v, nil
+		case untypedInt:
+			return -v, nil
+		case untypedFloat:
+			return -v, nil
 		default:
-			return nil, ErrInvalidOperation{}
+			return nil, ErrInvalidOperation{Pos: n.Negated.Pos}
 		}
+
+	case n.Not != nil:
+		v, err := e.primary(n.Not)
+		if err != nil {
+			return nil, err
+		}
+		vb, ok := toBool(v)
+		if !ok {
+			return nil, ErrInvalidOperation{Pos: n.Not.Pos}
+		}
+		return !vb, nil
 	}
 	panic("unreachable")
 }
 
 func (e *evaluator) primary(n *ast.Primary) (interface{}, error) {
 	switch {
+	case n.Quantifier != nil:
+		return e.quantifier(n.Quantifier)
+
+	case n.CallExpr != nil && GraphFunctions[n.CallExpr.Ident] != nil:
+		return e.graphCall(n)
+
+	case n.CallExpr != nil && ArrayFunctions[n.CallExpr.Ident] != nil:
+		return e.arrayCall(n)
+
+	case n.CallExpr != nil && n.CallExpr.Ident == "re":
+		return e.reCall(n)
+
 	case n.CallExpr != nil:
 		args := []interface{}{}
-		for _, a := range n.CallExpr.Args {
-			v, err := e.expr(&a)
+		for i := range n.CallExpr.Args {
+			v, err := e.expr(&n.CallExpr.Args[i])
 			if err != nil {
 				return nil, err
 			}
-			args = append(args, v)
+			args = append(args, defaultType(v))
+		}
+		f := e.program.funcs[n.CallExpr]
+		if f == nil {
+			return nil, ErrUndefined{Pos: n.Pos, Name: n.CallExpr.Ident}
 		}
-		return Functions[n.CallExpr.Ident](args...)
+		return f(args...)
+
+	case n.Conversion != nil:
+		v, err := e.expr(&n.Conversion.Expr)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := cast(v, n.Conversion.Type)
+		if !ok {
+			return nil, ErrInvalidOperation{Pos: n.Pos}
+		}
+		return v, nil
 
 	case n.Variable != nil:
 		v, ok := e.Values[n.Variable.Ident]
 		if !ok {
 			return nil, ErrUndefined{Pos: n.Pos, Name: n.Variable.Ident}
 		}
-		for _, i := range n.Variable.Indices {
-			r, err := e.expr(&i)
+		indices := n.Variable.Indices
+		if n.Variable.High != nil {
+			indices = indices[:len(indices)-1]
+		}
+		v, err := e.index(v, indices)
+		if err != nil {
+			return nil, err
+		}
+		if n.Variable.High != nil {
+			v, err = e.slice(v, &n.Variable.Indices[len(indices)], n.Variable.High)
 			if err != nil {
 				return nil, err
 			}
-			ri, ok := r.(int)
-			if !ok {
-				return nil, ErrNonIntegerIndex{Pos: i.Pos}
-			}
-			v = v.Index(ri)
 		}
-		if v.Kind() == reflect.Ptr {
-			return v.Elem().Interface(), nil
+		if v.Kind() == reflect.Ptr && v.Type() != Types["bigint"] {
+			v = v.Elem()
+		}
+		if b, ok := v.Interface().(*big.Int); ok && b == nil {
+			// A bigint that was never scanned is zero, as other numbers are.
+			return new(big.Int), nil
 		}
 		return v.Interface(), nil
 
@@ -768,25 +1086,176 @@ func (e *evaluator) primary(n *ast.Primary) (interface{}, error) {
 	panic("unreachable")
 }
 
+// reCall matches a string against a regular expression, compiling the
+// expression only if the program has not.
+func (e *evaluator) reCall(n *ast.Primary) (interface{}, error) {
+	args := n.CallExpr.Args
+	if len(args) != 2 {
+		return nil, ErrInvalidArgument{}
+	}
+	s, err := e.expr(&args[0])
+	if err != nil {
+		return nil, err
+	}
+	x, err := e.expr(&args[1])
+	if err != nil {
+		return nil, err
+	}
+	ss, xs, err := reArgs([]interface{}{s, x})
+	if err != nil {
+		return nil, err
+	}
+	re, ok := e.program.regexps[xs]
+	if !ok {
+		re, ok = e.regexps[xs]
+	}
+	if !ok {
+		re, err = regexp.Compile(xs)
+		if err != nil {
+			return nil, ErrInvalidRegexp{Pos: args[1].Pos, Err: err}
+		}
+		if e.regexps == nil {
+			e.regexps = map[string]*regexp.Regexp{}
+		}
+		e.regexps[xs] = re
+	}
+	return re.MatchString(ss), nil
+}
+
+// quantifier evaluates an all or exists quantifier. When all fails, the
+// failing iteration and the indexed values involved are kept for the check
+// error.
+func (e *evaluator) quantifier(n *ast.Quantifier) (interface{}, error) {
+	l, err := e.expr(&n.Low)
+	if err != nil {
+		return nil, err
+	}
+	li, ok := toInt(l)
+	if !ok {
+		return nil, ErrInvalidOperation{Pos: n.Pos}
+	}
+	h, err := e.expr(&n.High)
+	if err != nil {
+		return nil, err
+	}
+	hi, ok := toInt(h)
+	if !ok {
+		return nil, ErrInvalidOperation{Pos: n.Pos}
+	}
+
+	outer, defined := e.Values[n.Index]
+	defer func() {
+		if defined {
+			e.Values[n.Index] = outer
+		} else {
+			delete(e.Values, n.Index)
+		}
+	}()
+
+	all := n.Kind == "all"
+	for i := li; i < hi; i++ {
+		x := i
+		e.Values[n.Index] = reflect.ValueOf(&x)
+		e.quantified, e.operands = nil, nil
+		v, err := e.expr(&n.Expr)
+		if err != nil {
+			return nil, err
+		}
+		vb, ok := toBool(v)
+		if !ok {
+			return nil, ErrInvalidOperation{Pos: n.Pos}
+		}
+		switch {
+		case all && !vb:
+			if e.operands == nil {
+				e.operands = e.operandsOf(&n.Expr)
+			}
+			e.quantified = append([]Iteration{{Index: n.Index, Value: i}}, e.quantified...)
+			return false, nil
+		case !all && vb:
+			e.quantified, e.operands = nil, nil
+			return true, nil
+		}
+	}
+	e.quantified, e.operands = nil, nil
+	return all, nil
+}
+
+// operandsOf evaluates the first few distinct indexed variables in x, leaving
+// out any that cannot be evaluated.
+func (e *evaluator) operandsOf(x *ast.Expr) []Operand {
+	os := []Operand{}
+	seen := map[string]bool{}
+	ast.Inspect(x, func(n ast.Node) bool {
+		if len(os) == 3 {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Quantifier:
+			return false
+		case *ast.Variable:
+			if len(n.Indices) == 0 {
+				return true
+			}
+			s := string(formatTokens(n.Tokens))
+			if seen[s] {
+				return false
+			}
+			seen[s] = true
+			v, err := e.primary(&ast.Primary{Variable: n})
+			if err == nil {
+				os = append(os, Operand{Tokens: n.Tokens, Value: v})
+			}
+			return false
+		}
+		return true
+	})
+	return os
+}
+
 func (e *evaluator) basicLit(n *ast.BasicLit) (interface{}, error) {
 	switch {
 	case n.FloatLit != nil:
-		return *n.FloatLit, nil
+		return untypedFloat(*n.FloatLit), nil
 
 	case n.IntLit != nil:
-		return *n.IntLit, nil
+		return untypedInt(*n.IntLit), nil
 
 	case n.StringLit != nil:
 		return *n.StringLit, nil
+
+	case n.CharLit != nil:
+		return byte(*n.CharLit), nil
+
+	case n.BoolLit != nil:
+		return bool(*n.BoolLit), nil
 	}
 
 	panic("unreachable")
 }
 
+// enrichError sets the position of the statement being evaluated, pos, on an
+// error from the input, which cannot know it.
 func (e *evaluator) enrichError(err error, pos lexer.Position) error {
 	switch err := err.(type) {
 	case ErrBadParse:
 		err.Pos = pos
+		return err
+	case ErrWhitespace:
+		err.Pos = pos
+		return err
+	case ErrCRLF:
+		err.Pos = pos
+		return err
+	case ErrLoneCR:
+		err.Pos = pos
+		return err
+	case ErrBOM:
+		err.Pos = pos
+		return err
+	case ErrNoFinalNewline:
+		err.Pos = pos
+		return err
 	}
 	return err
 }
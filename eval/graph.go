@@ -0,0 +1,246 @@
+package eval
+
+import (
+	"reflect"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
+
+// GraphFunctions check a property of the graph whose vertices are 1 to n and
+// whose i-th edge joins U[i] and V[i]. Along with the outcome, they return the
+// index of the edge found to violate the property, or -1 if no single edge is
+// to blame. An edge with an endpoint outside 1 to n violates every property
+// but simplicity, for which n is not given.
+var GraphFunctions = map[string]func(n int, u, v []int64) (bool, int){
+	"isSimple":    isSimple,
+	"isConnected": isConnected,
+	"isTree":      isTree,
+	"isDAG":       isDAG,
+	"isBipartite": isBipartite,
+}
+
+// Edge is an edge of a graph.
+type Edge struct {
+	Index int
+	U, V  int64
+}
+
+// isSimple reports whether the undirected graph has no self-loops and no
+// multiple edges.
+func isSimple(n int, u, v []int64) (bool, int) {
+	seen := map[[2]int64]bool{}
+	for i := range u {
+		a, b := u[i], v[i]
+		if a > b {
+			a, b = b, a
+		}
+		if a == b || seen[[2]int64{a, b}] {
+			return false, i
+		}
+		seen[[2]int64{a, b}] = true
+	}
+	return true, -1
+}
+
+// isConnected reports whether the undirected graph is connected.
+func isConnected(n int, u, v []int64) (bool, int) {
+	d := newDSU(n)
+	c := n
+	for i := range u {
+		if !inRange(n, u[i], v[i]) {
+			return false, i
+		}
+		if d.union(int(u[i]), int(v[i])) {
+			c--
+		}
+	}
+	return c <= 1, -1
+}
+
+// isTree reports whether the undirected graph is a tree.
+func isTree(n int, u, v []int64) (bool, int) {
+	if len(u) != n-1 {
+		return false, -1
+	}
+	d := newDSU(n)
+	for i := range u {
+		if !inRange(n, u[i], v[i]) || !d.union(int(u[i]), int(v[i])) {
+			return false, i
+		}
+	}
+	return true, -1
+}
+
+// isDAG reports whether the graph, with each edge directed from U[i] to V[i],
+// has no cycles. The edge reported closes a cycle.
+func isDAG(n int, u, v []int64) (bool, int) {
+	adj := make([][]int, n+1)
+	for i := range u {
+		if !inRange(n, u[i], v[i]) {
+			return false, i
+		}
+		adj[u[i]] = append(adj[u[i]], i)
+	}
+
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make([]int, n+1)
+	type frame struct{ x, next int }
+	for s := 1; s <= n; s++ {
+		if color[s] != white {
+			continue
+		}
+		color[s] = grey
+		stack := []frame{{s, 0}}
+		for len(stack) > 0 {
+			f := &stack[len(stack)-1]
+			if f.next == len(adj[f.x]) {
+				color[f.x] = black
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			i := adj[f.x][f.next]
+			f.next++
+			switch y := int(v[i]); color[y] {
+			case grey:
+				return false, i
+			case white:
+				color[y] = grey
+				stack = append(stack, frame{y, 0})
+			}
+		}
+	}
+	return true, -1
+}
+
+// isBipartite reports whether the vertices of the undirected graph can be
+// split into two sets such that every edge joins the sets. The edge reported
+// closes an odd cycle.
+func isBipartite(n int, u, v []int64) (bool, int) {
+	d := newDSU(n)
+	for i := range u {
+		if !inRange(n, u[i], v[i]) {
+			return false, i
+		}
+		a, pa := d.find(int(u[i]))
+		b, pb := d.find(int(v[i]))
+		if a == b {
+			if pa == pb {
+				return false, i
+			}
+			continue
+		}
+		d.parent[a] = b
+		d.parity[a] = pa ^ pb ^ 1
+	}
+	return true, -1
+}
+
+func inRange(n int, u, v int64) bool {
+	return 1 <= u && u <= int64(n) && 1 <= v && v <= int64(n)
+}
+
+// dsu is a disjoint-set forest over 0 to n that also tracks, for each element,
+// the parity of its path to the root.
+type dsu struct {
+	parent []int
+	parity []int
+}
+
+func newDSU(n int) *dsu {
+	d := dsu{
+		parent: make([]int, n+1),
+		parity: make([]int, n+1),
+	}
+	for i := range d.parent {
+		d.parent[i] = i
+	}
+	return &d
+}
+
+func (d *dsu) find(x int) (int, int) {
+	if d.parent[x] == x {
+		return x, 0
+	}
+	r, p := d.find(d.parent[x])
+	d.parent[x] = r
+	d.parity[x] ^= p
+	return r, d.parity[x]
+}
+
+// union joins the sets of x and y, reporting false if they were one already.
+func (d *dsu) union(x, y int) bool {
+	a, _ := d.find(x)
+	b, _ := d.find(y)
+	if a == b {
+		return false
+	}
+	d.parent[a] = b
+	return true
+}
+
+func (e *evaluator) graphCall(n *ast.Primary) (interface{}, error) {
+	args := n.CallExpr.Args
+	nv := 0
+	if n.CallExpr.Ident != "isSimple" {
+		if len(args) != 3 {
+			return nil, ErrInvalidArgument{}
+		}
+		v, err := e.expr(&args[0])
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		nv, ok = toInt(v)
+		if !ok || nv < 0 {
+			return nil, ErrInvalidArgument{}
+		}
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		return nil, ErrInvalidArgument{}
+	}
+
+	us, err := e.expr(&args[0])
+	if err != nil {
+		return nil, err
+	}
+	vs, err := e.expr(&args[1])
+	if err != nil {
+		return nil, err
+	}
+	u, uok := toInt64s(us)
+	v, vok := toInt64s(vs)
+	if !uok || !vok || len(u) != len(v) {
+		return nil, ErrInvalidArgument{}
+	}
+
+	ok, i := GraphFunctions[n.CallExpr.Ident](nv, u, v)
+	if !ok && i >= 0 {
+		e.edge = &Edge{Index: i, U: u[i], V: v[i]}
+		if c, ok := e.cursors[reflect.ValueOf(us).Index(i).Addr().Pointer()]; ok {
+			e.witness = &c
+		}
+	}
+	return ok, nil
+}
+
+// toInt64s converts a slice of integers to []int64.
+func toInt64s(v interface{}) ([]int64, bool) {
+	r := reflect.ValueOf(v)
+	if r.Kind() != reflect.Slice {
+		return nil, false
+	}
+	s := make([]int64, r.Len())
+	for i := range s {
+		x, ok := toInt64(r.Index(i).Interface())
+		if !ok {
+			return nil, false
+		}
+		s[i] = x
+	}
+	return s, true
+}
@@ -3,33 +3,74 @@ package eval
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"math/big"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
 type Input struct {
+	in io.Reader
 	sc *bufio.Scanner
 
+	// The buffer set with ScannerBuffer, if any.
+	buf []byte
+	max int
+
+	// Whether a token has been scanned.
+	begun bool
+
 	token []byte
 	ahead [][]byte
 	err   error
 
+	// The fields of a split token that are yet to be read, and where each
+	// begins.
+	fields  [][]byte
+	fieldAt []Cursor
+
 	cur, curnext Cursor
+
+	// Whether numbers must be in canonical form.
+	strictNumbers bool
+
+	// Whether tokens must be separated by exactly one space, and lines by a
+	// line feed only.
+	strictSpace bool
+
+	// How to treat CRLF and lone CR line endings, a byte order mark and a
+	// missing newline at the end of the input.
+	crlf, loneCR, bom, finalNewline Policy
 }
 
 func newInput(input io.Reader) (*Input, error) {
 	p := Input{
+		in:      input,
 		curnext: Cursor{1, 0},
 	}
-	sc := bufio.NewScanner(input)
+	return &p, nil
+}
+
+// start prepares the input for reading once its options are set. The input is
+// read through a lineReader only if a policy needs one.
+func (p *Input) start() {
+	r := p.in
+	if p.crlf.rewrites() || p.loneCR.rewrites() || p.bom.rewrites() || p.finalNewline.rewrites() {
+		r = newLineReader(r, p)
+	}
+	sc := bufio.NewScanner(r)
+	if p.buf != nil || p.max > 0 {
+		sc.Buffer(p.buf, p.max)
+	}
 	sc.Split(scanTokens)
 	p.sc = sc
-	return &p, nil
 }
 
 func (p *Input) readBool() (bool, error) {
-	b, err := p.next()
+	b, err := p.value()
 	if err != nil {
 		return false, err
 	}
@@ -40,8 +81,35 @@ func (p *Input) readBool() (bool, error) {
 	return v, nil
 }
 
+func (p *Input) readChar() (byte, error) {
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 1 {
+		return 0, ErrBadParse{Want: "char", Got: b, Cursor: p.cur}
+	}
+	return b[0], nil
+}
+
+// readChars reads a token of exactly n characters.
+func (p *Input) readChars(n int) ([]byte, error) {
+	b, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != n {
+		return nil, ErrBadParse{Want: fmt.Sprintf("%d characters", n), Got: b, Cursor: p.cur}
+	}
+	return b, nil
+}
+
 func (p *Input) readInt() (int, error) {
-	b, err := p.next()
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("int", b)
 	if err != nil {
 		return 0, err
 	}
@@ -53,7 +121,11 @@ func (p *Input) readInt() (int, error) {
 }
 
 func (p *Input) readInt64() (int64, error) {
-	b, err := p.next()
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("int64", b)
 	if err != nil {
 		return 0, err
 	}
@@ -64,8 +136,76 @@ func (p *Input) readInt64() (int64, error) {
 	return n, nil
 }
 
+func (p *Input) readUint32() (uint32, error) {
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("uint32", b)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(string(b), 10, 32)
+	if err != nil {
+		return 0, ErrBadParse{Want: "uint32", Got: b, Cursor: p.cur}
+	}
+	return uint32(n), nil
+}
+
+func (p *Input) readUint64() (uint64, error) {
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("uint64", b)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0, ErrBadParse{Want: "uint64", Got: b, Cursor: p.cur}
+	}
+	return n, nil
+}
+
+func (p *Input) readBigInt() (*big.Int, error) {
+	b, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	err = p.checkNumber("bigint", b)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := new(big.Int).SetString(string(b), 10)
+	if !ok {
+		return nil, ErrBadParse{Want: "bigint", Got: b, Cursor: p.cur}
+	}
+	return n, nil
+}
+
+func (p *Input) readDecimal() (Decimal, error) {
+	b, err := p.value()
+	if err != nil {
+		return Decimal{}, err
+	}
+	err = p.checkNumber("decimal", b)
+	if err != nil {
+		return Decimal{}, err
+	}
+	d, ok := parseDecimal(string(b))
+	if !ok {
+		return Decimal{}, ErrBadParse{Want: "decimal", Got: b, Cursor: p.cur}
+	}
+	return d, nil
+}
+
 func (p *Input) readFloat32() (float32, error) {
-	b, err := p.next()
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("float32", b)
 	if err != nil {
 		return 0, err
 	}
@@ -77,7 +217,11 @@ func (p *Input) readFloat32() (float32, error) {
 }
 
 func (p *Input) readFloat64() (float64, error) {
-	b, err := p.next()
+	b, err := p.value()
+	if err != nil {
+		return 0, err
+	}
+	err = p.checkNumber("float64", b)
 	if err != nil {
 		return 0, err
 	}
@@ -88,8 +232,51 @@ func (p *Input) readFloat64() (float64, error) {
 	return float64(f), nil
 }
 
+// checkNumber returns an ErrBadParse if numbers must be in canonical form and
+// b is not.
+func (p *Input) checkNumber(want string, b []byte) error {
+	if !p.strictNumbers {
+		return nil
+	}
+	reason := numberFault(string(b))
+	if reason == "" {
+		return nil
+	}
+	return ErrBadParse{Want: want, Got: b, Cursor: p.cur, Reason: reason}
+}
+
+// numberFault returns why s is not a number in canonical form, or "" if it is
+// one or is not a number at all.
+func numberFault(s string) string {
+	_, err := strconv.ParseFloat(s, 64)
+	if errors.Is(err, strconv.ErrSyntax) {
+		return ""
+	}
+	t := strings.TrimPrefix(s, "-")
+	switch {
+	case s[0] == '+':
+		return "explicit plus sign"
+	case strings.EqualFold(t, "nan"), strings.EqualFold(t, "inf"), strings.EqualFold(t, "infinity"):
+		return "special value"
+	case strings.ContainsAny(t, "xX"):
+		return "hexadecimal notation"
+	case strings.ContainsAny(t, "eE"):
+		return "exponent"
+	}
+	i, f, point := strings.Cut(t, ".")
+	switch {
+	case point && (i == "" || f == ""):
+		return "missing digits around decimal point"
+	case len(i) > 1 && i[0] == '0':
+		return "leading zero"
+	case len(t) < len(s) && strings.Trim(i+f, "0") == "":
+		return "negative zero"
+	}
+	return ""
+}
+
 func (p *Input) readString() (string, error) {
-	b, err := p.next()
+	b, err := p.value()
 	if err != nil {
 		return "", err
 	}
@@ -104,6 +291,71 @@ func (p *Input) readStringLn() (string, error) {
 	return string(b), nil
 }
 
+// value returns the next token to be read as a value, or the next field of
+// the token last split.
+func (p *Input) value() ([]byte, error) {
+	if len(p.fields) > 0 {
+		b := p.fields[0]
+		p.token = b
+		p.cur = p.fieldAt[0]
+		p.fields = p.fields[1:]
+		p.fieldAt = p.fieldAt[1:]
+		return b, nil
+	}
+
+	b, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if p.strictSpace && bytes.Equal(b, []byte("\n")) {
+		reason := "unexpected line break"
+		if p.cur.Col == 0 {
+			reason = "empty line"
+		}
+		return nil, ErrWhitespace{Got: b, Cursor: p.cur, Reason: reason}
+	}
+	return b, nil
+}
+
+// split reads the next token and splits it into n fields separated by sep,
+// which the following reads return in turn.
+func (p *Input) split(sep string, n int) error {
+	b, err := p.value()
+	if err != nil {
+		return err
+	}
+	fields := bytes.Split(b, []byte(sep))
+	if len(fields) != n {
+		return ErrBadParse{Want: fmt.Sprintf("%d fields separated by %q", n, sep), Got: b, Cursor: p.cur}
+	}
+	at := p.cur
+	for _, f := range fields {
+		p.fields = append(p.fields, f)
+		p.fieldAt = append(p.fieldAt, at)
+		at.Col += len(f) + len(sep)
+	}
+	return nil
+}
+
+// splitWidth reads the next token and splits it into n fields of w characters
+// each.
+func (p *Input) splitWidth(w, n int) error {
+	b, err := p.value()
+	if err != nil {
+		return err
+	}
+	if len(b)%w != 0 || len(b)/w != n {
+		return ErrBadParse{Want: fmt.Sprintf("%d characters", w*n), Got: b, Cursor: p.cur}
+	}
+	at := p.cur
+	for k := 0; k < n; k++ {
+		p.fields = append(p.fields, b[k*w:(k+1)*w])
+		p.fieldAt = append(p.fieldAt, at)
+		at.Col += w
+	}
+	return nil
+}
+
 func (p *Input) isAtEOL() (bool, error) {
 	b, err := p.next()
 	if err != nil {
@@ -139,6 +391,12 @@ func (p *Input) next() ([]byte, error) {
 			p.curnext.Col += len(b)
 		}
 
+		if p.strictSpace {
+			if reason := p.spaceFault(r); reason != "" {
+				return nil, ErrWhitespace{Got: b, Cursor: p.cur, Reason: reason}
+			}
+		}
+
 		if !isSpace(r) && len(p.ahead) == 2 {
 			r0, _ := utf8.DecodeRune(p.ahead[0])
 			r1, _ := utf8.DecodeRune(p.ahead[1])
@@ -157,6 +415,33 @@ func (p *Input) next() ([]byte, error) {
 	return nil, p.err
 }
 
+// spaceNames names the whitespace characters other than space and line feed.
+var spaceNames = map[rune]string{
+	'\t': "tab",
+	'\v': "vertical tab",
+	'\f': "form feed",
+	'\r': "carriage return",
+}
+
+// spaceFault returns why the token starting with r, which next has just
+// returned, is whitespace out of place in strict mode, or "" if it is not.
+func (p *Input) spaceFault(r rune) string {
+	switch {
+	case !isSpace(r), r == '\n':
+		return ""
+	case r != ' ':
+		if s, ok := spaceNames[r]; ok {
+			return s
+		}
+		return "non-ASCII space"
+	case p.cur.Col == 0:
+		return "leading space"
+	case len(p.ahead) == 0, bytes.Equal(p.ahead[0], []byte("\n")):
+		return "trailing space"
+	}
+	return "extra space"
+}
+
 func (p *Input) nextLn() ([]byte, error) {
 	if p.err != nil {
 		return nil, p.err
@@ -198,6 +483,21 @@ func (p *Input) scan() {
 			return
 		}
 		b := p.sc.Bytes()
+		if !p.begun {
+			p.begun = true
+			if p.bom == Accept {
+				b = bytes.TrimPrefix(b, bom)
+				if len(b) == 0 {
+					continue
+				}
+			}
+		}
+		if p.crlf == Accept && !p.strictSpace && bytes.Equal(b, []byte("\n")) {
+			// Read the CR of a CRLF as part of the line ending.
+			if k := len(p.ahead) - 1; k >= 0 && bytes.Equal(p.ahead[k], []byte("\r")) {
+				p.ahead = p.ahead[:k]
+			}
+		}
 		t := make([]byte, len(b))
 		copy(t, b)
 		p.ahead = append(p.ahead, t)
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Policy is how an evaluation treats an irregularity in the input: a CRLF or
+// lone CR line ending, a byte order mark, or a missing newline at the end of
+// the input.
+type Policy int
+
+const (
+	// Preserve leaves the input as it is, and is the default. The CR of a
+	// CRLF is whitespace, and a byte order mark is part of the first token.
+	Preserve Policy = iota
+
+	// Accept reads the input as if it were regular: a CRLF ends a line, unless
+	// strict whitespace is required, and a byte order mark is skipped. A lone
+	// CR is whitespace, and input may end without a newline.
+	Accept
+
+	// Normalize corrects the input before it is read: line endings become
+	// LF, a byte order mark is dropped and a missing final newline is added.
+	Normalize
+
+	// Reject fails the evaluation with an error naming the irregularity.
+	Reject
+)
+
+// rewrites reports whether the policy needs the input to be read through a
+// lineReader.
+func (q Policy) rewrites() bool {
+	return q == Normalize || q == Reject
+}
+
+var bom = []byte("\xef\xbb\xbf")
+
+// lineReader applies the line ending and byte order mark policies of an
+// Input to the input it reads from.
+type lineReader struct {
+	r *bufio.Reader
+	p *Input
+
+	started bool
+	last    byte
+	cur     Cursor // After the bytes read so far, as normalized.
+	err     error
+}
+
+func newLineReader(r io.Reader, p *Input) *lineReader {
+	return &lineReader{
+		r:   bufio.NewReader(r),
+		p:   p,
+		cur: Cursor{1, 0},
+	}
+}
+
+func (l *lineReader) Read(b []byte) (int, error) {
+	if !l.started {
+		l.started = true
+		if l.p.bom.rewrites() {
+			head, _ := l.r.Peek(len(bom))
+			if bytes.Equal(head, bom) {
+				if l.p.bom == Reject {
+					l.err = ErrBOM{Cursor: l.cur}
+					return 0, l.err
+				}
+				l.r.Discard(len(bom))
+			}
+		}
+	}
+
+	n := 0
+	for n < len(b) && l.err == nil {
+		c, err := l.r.ReadByte()
+		if err != nil {
+			l.err = err
+			if err == io.EOF && l.last != '\n' && l.cur != (Cursor{1, 0}) {
+				switch l.p.finalNewline {
+				case Normalize:
+					b[n] = '\n'
+					n++
+				case Reject:
+					l.err = ErrNoFinalNewline{Cursor: l.cur}
+				}
+			}
+			break
+		}
+
+		if c == '\r' {
+			next, _ := l.r.Peek(1)
+			policy, lf := l.p.loneCR, false
+			if len(next) == 1 && next[0] == '\n' {
+				policy, lf = l.p.crlf, true
+			}
+			switch policy {
+			case Normalize:
+				if lf {
+					continue
+				}
+				c = '\n'
+			case Reject:
+				if lf {
+					l.err = ErrCRLF{Cursor: l.cur}
+				} else {
+					l.err = ErrLoneCR{Cursor: l.cur}
+				}
+				continue
+			}
+		}
+
+		b[n] = c
+		n++
+		l.last = c
+		if c == '\n' {
+			l.cur.Ln++
+			l.cur.Col = 0
+		} else {
+			l.cur.Col++
+		}
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+	return 0, l.err
+}
@@ -0,0 +1,212 @@
+package eval
+
+import (
+	"cmp"
+	"math/big"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// binaryOp applies the arithmetic or bitwise operator op to l and r, once
+// both are promoted to their common type.
+func binaryOp(pos lexer.Position, op ast.Operator, l, r interface{}) (interface{}, error) {
+	l, r, ok := promote(l, r)
+	if !ok {
+		return nil, ErrInvalidOperation{Pos: pos}
+	}
+	switch l := l.(type) {
+	case byte:
+		return integerOp(pos, op, l, r.(byte))
+	case int:
+		return integerOp(pos, op, l, r.(int))
+	case int64:
+		return integerOp(pos, op, l, r.(int64))
+	case uint32:
+		return integerOp(pos, op, l, r.(uint32))
+	case uint64:
+		return integerOp(pos, op, l, r.(uint64))
+	case *big.Int:
+		return bigOp(pos, op, l, r.(*big.Int))
+	case Decimal:
+		return decimalOp(pos, op, l, r.(Decimal))
+	case float32:
+		return floatOp(pos, op, l, r.(float32))
+	case float64:
+		return floatOp(pos, op, l, r.(float64))
+
+	case untypedInt:
+		v, err := bigOp(pos, op, big.NewInt(int64(l)), big.NewInt(int64(r.(untypedInt))))
+		if err != nil {
+			return nil, err
+		}
+		return bigResult(v.(*big.Int)), nil
+
+	case untypedFloat:
+		v, err := floatOp(pos, op, float64(l), float64(r.(untypedFloat)))
+		if err != nil {
+			return nil, err
+		}
+		return untypedFloat(v.(float64)), nil
+	}
+	return nil, ErrInvalidOperation{Pos: pos}
+}
+
+func integerOp[T byte | int | int64 | uint32 | uint64](pos lexer.Position, op ast.Operator, l, r T) (interface{}, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "|":
+		return l | r, nil
+	case "xor":
+		return l ^ r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, ErrDivisionByZero{Pos: pos}
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, ErrDivisionByZero{Pos: pos}
+		}
+		return l % r, nil
+	case "<<":
+		if r < 0 {
+			return nil, ErrInvalidOperation{Pos: pos}
+		}
+		return l << r, nil
+	case ">>":
+		if r < 0 {
+			return nil, ErrInvalidOperation{Pos: pos}
+		}
+		return l >> r, nil
+	case "&":
+		return l & r, nil
+	}
+	return nil, ErrInvalidOperation{Pos: pos}
+}
+
+func bigOp(pos lexer.Position, op ast.Operator, l, r *big.Int) (interface{}, error) {
+	z := new(big.Int)
+	switch op {
+	case "+":
+		return z.Add(l, r), nil
+	case "-":
+		return z.Sub(l, r), nil
+	case "|":
+		return z.Or(l, r), nil
+	case "xor":
+		return z.Xor(l, r), nil
+	case "*":
+		return z.Mul(l, r), nil
+	case "/":
+		if r.Sign() == 0 {
+			return nil, ErrDivisionByZero{Pos: pos}
+		}
+		return z.Quo(l, r), nil
+	case "%":
+		if r.Sign() == 0 {
+			return nil, ErrDivisionByZero{Pos: pos}
+		}
+		return z.Rem(l, r), nil
+	case "<<":
+		if r.Sign() < 0 || r.Cmp(big.NewInt(maxBigBits)) > 0 {
+			return nil, ErrInvalidOperation{Pos: pos}
+		}
+		return z.Lsh(l, uint(r.Uint64())), nil
+	case ">>":
+		if r.Sign() < 0 {
+			return nil, ErrInvalidOperation{Pos: pos}
+		}
+		if !r.IsUint64() || r.Uint64() > uint64(l.BitLen()) {
+			return z.Rsh(l, uint(l.BitLen())), nil
+		}
+		return z.Rsh(l, uint(r.Uint64())), nil
+	case "&":
+		return z.And(l, r), nil
+	}
+	return nil, ErrInvalidOperation{Pos: pos}
+}
+
+// maxBigBits bounds the size of the integers that shifts and powers may
+// produce, so that a typo such as 10^10^9 fails rather than exhausting memory.
+const maxBigBits = 1 << 20
+
+func floatOp[T float32 | float64](pos lexer.Position, op ast.Operator, l, r T) (interface{}, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		return l / r, nil
+	}
+	return nil, ErrInvalidOperation{Pos: pos}
+}
+
+// compare applies the comparison operator op to l and r, once both are
+// promoted to their common type.
+func compare(pos lexer.Position, op ast.Operator, l, r interface{}) (bool, error) {
+	l, r, ok := promote(l, r)
+	if !ok {
+		return false, ErrInvalidOperation{Pos: pos}
+	}
+	switch l := l.(type) {
+	case bool:
+		switch op {
+		case "==":
+			return l == r.(bool), nil
+		case "!=":
+			return l != r.(bool), nil
+		}
+	case byte:
+		return compareOrdered(pos, op, l, r.(byte))
+	case int:
+		return compareOrdered(pos, op, l, r.(int))
+	case int64:
+		return compareOrdered(pos, op, l, r.(int64))
+	case uint32:
+		return compareOrdered(pos, op, l, r.(uint32))
+	case uint64:
+		return compareOrdered(pos, op, l, r.(uint64))
+	case *big.Int:
+		return compareOrdered(pos, op, l.Cmp(r.(*big.Int)), 0)
+	case Decimal:
+		return compareOrdered(pos, op, l.rat().Cmp(r.(Decimal).rat()), 0)
+	case float32:
+		return compareOrdered(pos, op, l, r.(float32))
+	case float64:
+		return compareOrdered(pos, op, l, r.(float64))
+	case string:
+		return compareOrdered(pos, op, l, r.(string))
+	case untypedInt:
+		return compareOrdered(pos, op, l, r.(untypedInt))
+	case untypedFloat:
+		return compareOrdered(pos, op, l, r.(untypedFloat))
+	}
+	return false, ErrInvalidOperation{Pos: pos}
+}
+
+func compareOrdered[T cmp.Ordered](pos lexer.Position, op ast.Operator, l, r T) (bool, error) {
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<=":
+		return l <= r, nil
+	case ">=":
+		return l >= r, nil
+	case "<":
+		return l < r, nil
+	case ">":
+		return l > r, nil
+	}
+	return false, ErrInvalidOperation{Pos: pos}
+}
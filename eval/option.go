@@ -10,8 +10,88 @@ func (f optionFunc) apply(e *evaluator) {
 	f(e)
 }
 
+// Params supplies the values of named constants. A parameter overrides the
+// value given in the constant's declaration, and is defined even if the spec
+// does not declare it. Values must be booleans, integers, floating-point
+// numbers or strings.
+func Params(params map[string]interface{}) Option {
+	return optionFunc(func(e *evaluator) {
+		for k, v := range params {
+			e.params[k] = v
+		}
+	})
+}
+
+// Subtasks selects the subtasks whose checks are enforced. Checks in other
+// subtasks are evaluated for the report only.
+func Subtasks(names ...string) Option {
+	return optionFunc(func(e *evaluator) {
+		for _, x := range names {
+			e.subtasks[x] = true
+		}
+	})
+}
+
+// ReportSubtasks fills report with the outcome of each subtask the
+// evaluation reached.
+func ReportSubtasks(report SubtaskReport) Option {
+	return optionFunc(func(e *evaluator) {
+		e.report = report
+	})
+}
+
+// StrictNumbers requires numbers in the input to be written in canonical form:
+// without a plus sign, leading zeros or an exponent, and not as negative zero
+// or a special value such as NaN. A scan statement ending in strict or lax
+// overrides this for the tokens it scans.
+func StrictNumbers() Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.strictNumbers = true
+	})
+}
+
+// StrictWhitespace requires tokens on a line to be separated by exactly one
+// space, and lines to end in a line feed, with no whitespace at either end of
+// a line. An empty line is allowed only where the spec expects one with eol.
+// Whitespace read by scanln is not checked.
+func StrictWhitespace() Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.strictSpace = true
+	})
+}
+
+// CRLF sets the policy for CRLF line endings.
+func CRLF(policy Policy) Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.crlf = policy
+	})
+}
+
+// LoneCR sets the policy for line endings that are a CR alone. Normalizing
+// one turns it into an LF.
+func LoneCR(policy Policy) Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.loneCR = policy
+	})
+}
+
+// BOM sets the policy for a UTF-8 byte order mark at the start of the input.
+func BOM(policy Policy) Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.bom = policy
+	})
+}
+
+// FinalNewline sets the policy for input that does not end in a newline.
+// Empty input is not affected.
+func FinalNewline(policy Policy) Option {
+	return optionFunc(func(e *evaluator) {
+		e.Input.finalNewline = policy
+	})
+}
+
 func ScannerBuffer(buf []byte, max int) Option {
 	return optionFunc(func(e *evaluator) {
-		e.Input.sc.Buffer(buf, max)
+		e.Input.buf, e.Input.max = buf, max
 	})
 }
@@ -0,0 +1,193 @@
+package eval
+
+import (
+	"io"
+	"regexp"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
+
+// Program is a scanspec prepared for evaluation. It can be run on any number
+// of inputs, concurrently if need be.
+type Program struct {
+	source *ast.Source
+
+	// The built-in function each call resolves to, other than graph and
+	// array functions, which are checked by name.
+	funcs map[*ast.CallExpr]func(args ...interface{}) (interface{}, error)
+
+	// The regular expressions given to re as constants, by pattern.
+	regexps map[string]*regexp.Regexp
+
+	// The values of the expressions that do not depend on any variable.
+	folded map[ast.Node]interface{}
+
+	// The names of the variables passed to graph and array functions.
+	tracked map[string]bool
+}
+
+// Compile prepares source for evaluation. It resolves the functions called in
+// source, checks the separators of scan statements, compiles the regular
+// expressions given to re as constants, and evaluates constant expressions
+// once.
+func Compile(source *ast.Source) (*Program, error) {
+	p := Program{
+		source:  source,
+		funcs:   map[*ast.CallExpr]func(args ...interface{}) (interface{}, error){},
+		regexps: map[string]*regexp.Regexp{},
+		folded:  map[ast.Node]interface{}{},
+		tracked: trackedArgs(source),
+	}
+
+	var err error
+	ast.Inspect(source, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Primary:
+			if n.CallExpr != nil {
+				err = p.resolve(n)
+			}
+		case *ast.ScanStmt:
+			if n.Sep != "" && (len(n.Sep) != 1 || isSpace(rune(n.Sep[0]))) {
+				err = ErrInvalidSeparator{Pos: n.Pos, Sep: n.Sep}
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ast.Inspect(source, func(n ast.Node) bool {
+		if !isConstant(n) {
+			return true
+		}
+		v, ok := p.fold(n)
+		if !ok {
+			return true
+		}
+		p.folded[n] = v
+		return false
+	})
+
+	ast.Inspect(source, func(n ast.Node) bool {
+		if n, ok := n.(*ast.Primary); ok && n.CallExpr != nil && n.CallExpr.Ident == "re" && len(n.CallExpr.Args) == 2 && err == nil {
+			x, ok := p.folded[&n.CallExpr.Args[1]].(string)
+			if !ok {
+				return true
+			}
+			p.regexps[x], err = regexp.Compile(x)
+			if err != nil {
+				err = ErrInvalidRegexp{Pos: n.CallExpr.Args[1].Pos, Err: err}
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// resolve resolves the function called by n.
+func (p *Program) resolve(n *ast.Primary) error {
+	name := n.CallExpr.Ident
+	switch {
+	case GraphFunctions[name] != nil, ArrayFunctions[name] != nil, name == "re":
+		return nil
+	case Functions[name] != nil:
+		p.funcs[n.CallExpr] = Functions[name]
+		return nil
+	}
+	return ErrUndefined{Pos: n.Pos, Name: name}
+}
+
+// fold evaluates the constant expression n. It reports false if n cannot be
+// evaluated, leaving the error for Run to report where it occurs.
+func (p *Program) fold(n ast.Node) (v interface{}, ok bool) {
+	defer func() {
+		if recover() != nil {
+			v, ok = nil, false
+		}
+	}()
+
+	e := evaluator{program: p, Values: Values{}}
+	var err error
+	switch n := n.(type) {
+	case *ast.Expr:
+		v, err = e.expr(n)
+	case *ast.Relative:
+		v, err = e.relative(n)
+	case *ast.Addition:
+		v, err = e.addition(n)
+	case *ast.Multiplication:
+		v, err = e.multiplication(n)
+	}
+	return v, err == nil
+}
+
+// isConstant reports whether n is an expression that depends on no variable.
+func isConstant(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.Expr, *ast.Relative, *ast.Addition, *ast.Multiplication:
+	default:
+		return false
+	}
+	c := true
+	ast.Inspect(n, func(n ast.Node) bool {
+		if _, ok := n.(*ast.Variable); ok {
+			c = false
+		}
+		return c
+	})
+	return c
+}
+
+// Run evaluates the program on input.
+func (p *Program) Run(input io.Reader, options ...Option) (values Values, err error) {
+	e := evaluator{
+		program:  p,
+		Values:   Values{},
+		params:   map[string]interface{}{},
+		reducers: map[string][]*reducer{},
+		subtasks: map[string]bool{},
+		report:   SubtaskReport{},
+		cursors:  map[uintptr]Cursor{},
+	}
+	e.Input, err = newInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range options {
+		o.apply(&e)
+	}
+	e.Input.start()
+
+	for k, v := range e.params {
+		c, ok := untyped(v)
+		if !ok {
+			return nil, ErrInvalidParam{Name: k}
+		}
+		e.Values[k] = constant(c)
+	}
+
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		switch v := v.(type) {
+		case error:
+			err = v
+		default:
+			panic(v)
+		}
+	}()
+
+	ast.Walk(&e, p.source)
+	return e.Values, nil
+}
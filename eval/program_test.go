@@ -0,0 +1,63 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
+
+func TestCompileFolds(t *testing.T) {
+	n, err := ast.ParseString("inputspec", `var A [3]int
+scan A[0], A[1], A[2]
+check A[pow(2, 1)] == 3, count(A, pow(2, 0)) == 1, isPermutation(A, pow(3, 1))
+check pow(2, 2) == 4, pow(A[0], 1) == 1
+eol
+eof
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Compile(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only pow(A[0], 1) depends on a variable. Every other call to pow is
+	// folded by Compile, and must not be evaluated again by Run.
+	calls := 0
+	for c := range p.funcs {
+		if c.Ident == "pow" {
+			p.funcs[c] = func(args ...interface{}) (interface{}, error) {
+				calls++
+				return pow(args[0], args[1])
+			}
+		}
+	}
+	_, err = p.Run(strings.NewReader("1 2 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("pow evaluated %d times, want 1", calls)
+	}
+}
+
+func TestRunBadArity(t *testing.T) {
+	for _, c := range []string{
+		"len()", "len(A, A)", "pow(N)", "min()", "max(A, A)", "count(A)",
+	} {
+		n, err := ast.ParseString("inputspec", "var N int\nvar A [1]int\nscan N, A[0]\ncheck "+c+" > 0\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Compile(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Run(strings.NewReader("1 2\n"))
+		if _, ok := err.(ErrInvalidArgument); !ok {
+			t.Errorf("%s: got %v, want ErrInvalidArgument", c, err)
+		}
+	}
+}
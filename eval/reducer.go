@@ -0,0 +1,93 @@
+package eval
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// Reducer folds v into acc, the value of a let variable after n values have
+// been folded into it.
+type Reducer func(acc, v interface{}, n int) (interface{}, error)
+
+var Reducers = map[string]Reducer{
+	"sum": func(acc, v interface{}, n int) (interface{}, error) {
+		a, aok := toBigInt(acc)
+		b, bok := toBigInt(v)
+		if aok && bok {
+			return a.Add(a, b), nil
+		}
+		return binaryOp(lexer.Position{}, "+", acc, v)
+	},
+
+	"product": func(acc, v interface{}, n int) (interface{}, error) {
+		if n == 0 {
+			return v, nil
+		}
+		a, aok := toBigInt(acc)
+		b, bok := toBigInt(v)
+		if aok && bok {
+			return a.Mul(a, b), nil
+		}
+		return binaryOp(lexer.Position{}, "*", acc, v)
+	},
+
+	"min": func(acc, v interface{}, n int) (interface{}, error) {
+		if n == 0 {
+			return v, nil
+		}
+		less, err := compare(lexer.Position{}, "<", v, acc)
+		if err != nil {
+			return nil, err
+		}
+		if less {
+			return v, nil
+		}
+		return acc, nil
+	},
+
+	"max": func(acc, v interface{}, n int) (interface{}, error) {
+		if n == 0 {
+			return v, nil
+		}
+		greater, err := compare(lexer.Position{}, ">", v, acc)
+		if err != nil {
+			return nil, err
+		}
+		if greater {
+			return v, nil
+		}
+		return acc, nil
+	},
+
+	"count": func(acc, v interface{}, n int) (interface{}, error) {
+		return int64(n + 1), nil
+	},
+}
+
+// narrow converts v to type t, reporting false if v is out of its range.
+func narrow(v interface{}, t string) (interface{}, bool) {
+	if t == "bigint" {
+		return toBigInt(v)
+	}
+	if b, ok := v.(*big.Int); ok {
+		switch {
+		case b.IsInt64():
+			v = b.Int64()
+		case b.IsUint64():
+			v = b.Uint64()
+		default:
+			return nil, false
+		}
+	}
+	switch t {
+	case "int":
+		n, ok := toInt64(v)
+		if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, false
+		}
+		return int(n), true
+	}
+	return convert(v, t)
+}
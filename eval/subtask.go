@@ -0,0 +1,20 @@
+package eval
+
+import "sort"
+
+// SubtaskReport maps the name of each subtask to the first of its checks that
+// the input failed, or to nil if the input satisfies the subtask.
+type SubtaskReport map[string]error
+
+// Satisfied returns the names of the subtasks the input satisfies, in sorted
+// order.
+func (r SubtaskReport) Satisfied() []string {
+	names := []string{}
+	for k, err := range r {
+		if err == nil {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,14 +1,37 @@
 package eval
 
-import "reflect"
+import (
+	"math/big"
+	"reflect"
+)
 
 var Types = map[string]reflect.Type{
 	"bool":    reflect.TypeOf(bool(false)),
+	"char":    reflect.TypeOf(byte(0)),
 	"int":     reflect.TypeOf(int(0)),
 	"int64":   reflect.TypeOf(int64(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"bigint":  reflect.TypeOf((*big.Int)(nil)),
+	"decimal": reflect.TypeOf(Decimal{}),
 	"float32": reflect.TypeOf(float32(0)),
 	"float64": reflect.TypeOf(float64(0)),
 	"string":  reflect.TypeOf(string("")),
 }
 
 type Values map[string]reflect.Value
+
+// snapshot returns a copy of vs that keeps the current values of scalar
+// variables as they change.
+func (vs Values) snapshot() Values {
+	c := Values{}
+	for k, v := range vs {
+		if v.Kind() == reflect.Ptr {
+			p := reflect.New(v.Elem().Type())
+			p.Elem().Set(v.Elem())
+			v = p
+		}
+		c[k] = v
+	}
+	return c
+}
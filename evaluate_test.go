@@ -2,9 +2,11 @@ package scanlib
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -32,6 +34,10 @@ func TestEvaluate(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			p, err := eval.Compile(n)
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			pis, err := os.ReadDir(filepath.Join("./testdata", fi.Name(), "inputs"))
 			if err != nil {
@@ -52,7 +58,33 @@ func TestEvaluate(t *testing.T) {
 
 					errstr, _ := os.ReadFile(filepath.Join("./testdata", fi.Name(), "inputs", strings.TrimSuffix(pi.Name(), ".in")+".err"))
 
-					_, err = eval.Evaluate(n, bytes.NewReader(instr))
+					options := []eval.Option{}
+					paramsrc, err := os.ReadFile(filepath.Join("./testdata", fi.Name(), "inputs", strings.TrimSuffix(pi.Name(), ".in")+".params"))
+					if err == nil {
+						params, err := parseParams(paramsrc)
+						if err != nil {
+							t.Fatal(err)
+						}
+						options = append(options, eval.Params(params))
+					}
+					subtaskstr, err := os.ReadFile(filepath.Join("./testdata", fi.Name(), "inputs", strings.TrimSuffix(pi.Name(), ".in")+".subtasks"))
+					if err == nil {
+						options = append(options, eval.Subtasks(strings.Fields(string(subtaskstr))...))
+					}
+					optionstr, err := os.ReadFile(filepath.Join("./testdata", fi.Name(), "inputs", strings.TrimSuffix(pi.Name(), ".in")+".options"))
+					if err == nil {
+						for _, x := range strings.Fields(string(optionstr)) {
+							o, ok := testOptions[x]
+							if !ok {
+								t.Fatalf("unknown option %q", x)
+							}
+							options = append(options, o)
+						}
+					}
+					report := eval.SubtaskReport{}
+					options = append(options, eval.ReportSubtasks(report))
+
+					_, err = p.Run(bytes.NewReader(instr), options...)
 					if err != nil {
 						if err.Error() != string(errstr) {
 							t.Fatalf("want err == %q, got %q", string(errstr), err.Error())
@@ -62,8 +94,74 @@ func TestEvaluate(t *testing.T) {
 							t.Fatalf("want err == %q, got nil", string(errstr))
 						}
 					}
+
+					reportstr, err := os.ReadFile(filepath.Join("./testdata", fi.Name(), "inputs", strings.TrimSuffix(pi.Name(), ".in")+".report"))
+					if err == nil {
+						if got := formatReport(report); got != string(reportstr) {
+							t.Fatalf("want report == %q, got %q", string(reportstr), got)
+						}
+					}
 				})
 			}
 		})
 	}
 }
+
+// testOptions are the options a test input can select in its .options file.
+var testOptions = map[string]eval.Option{
+	"strictNumbers":         eval.StrictNumbers(),
+	"strictWhitespace":      eval.StrictWhitespace(),
+	"normalizeCRLF":         eval.CRLF(eval.Normalize),
+	"rejectCRLF":            eval.CRLF(eval.Reject),
+	"acceptCRLF":            eval.CRLF(eval.Accept),
+	"normalizeLoneCR":       eval.LoneCR(eval.Normalize),
+	"rejectLoneCR":          eval.LoneCR(eval.Reject),
+	"normalizeBOM":          eval.BOM(eval.Normalize),
+	"rejectBOM":             eval.BOM(eval.Reject),
+	"acceptBOM":             eval.BOM(eval.Accept),
+	"normalizeFinalNewline": eval.FinalNewline(eval.Normalize),
+	"rejectFinalNewline":    eval.FinalNewline(eval.Reject),
+}
+
+// parseParams decodes a JSON object of spec parameters, keeping integers as
+// int64.
+func parseParams(b []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	params := map[string]interface{}{}
+	err := dec.Decode(&params)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range params {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if i, err := n.Int64(); err == nil {
+			params[k] = i
+		} else if params[k], err = n.Float64(); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// formatReport lists the subtasks in report in sorted order, one per line,
+// each followed by "ok" or the check it failed.
+func formatReport(report eval.SubtaskReport) string {
+	names := []string{}
+	for k := range report {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	s := ""
+	for _, k := range names {
+		if report[k] == nil {
+			s += k + " ok\n"
+		} else {
+			s += k + " " + report[k].Error() + "\n"
+		}
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Furqan Software Ltd. All rights reserved.
+
+package code
+
+import (
+	"math/big"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
+
+// maxConstBits bounds the size of the constants ConstInt folds.
+const maxConstBits = 1 << 12
+
+// ConstInt returns the value of n if it is an integer constant expression of
+// literals and the named constants in consts. A power of such constants, which
+// C++ and Go have no operator for, can be folded to a literal instead.
+func ConstInt(n ast.Node, consts map[string]*big.Int) (*big.Int, bool) {
+	switch n := n.(type) {
+	case *ast.Expr:
+		if len(n.Right) == 0 {
+			return ConstInt(n.Left, consts)
+		}
+
+	case *ast.LogicalOr:
+		if len(n.Right) == 0 {
+			return ConstInt(n.Left, consts)
+		}
+
+	case *ast.LogicalAnd:
+		if len(n.Right) == 0 && n.Interval == nil {
+			return ConstInt(n.Left, consts)
+		}
+
+	case *ast.Relative:
+		v, ok := ConstInt(n.Left, consts)
+		for _, c := range n.Right {
+			if !ok {
+				break
+			}
+			var r *big.Int
+			r, ok = ConstInt(c.Addition, consts)
+			if ok {
+				v, ok = constOp(c.Operator, v, r)
+			}
+		}
+		return v, ok
+
+	case *ast.Addition:
+		v, ok := ConstInt(n.Left, consts)
+		for _, c := range n.Right {
+			if !ok {
+				break
+			}
+			var r *big.Int
+			r, ok = ConstInt(c.Factor, consts)
+			if ok {
+				v, ok = constOp(c.Operator, v, r)
+			}
+		}
+		return v, ok
+
+	case *ast.Multiplication:
+		v, ok := ConstInt(n.Unary, consts)
+		if !ok || n.Exponent == nil {
+			return v, ok
+		}
+		e, ok := ConstInt(n.Exponent, consts)
+		if !ok || e.Sign() < 0 || !e.IsInt64() {
+			return nil, false
+		}
+		if v.CmpAbs(big.NewInt(1)) > 0 && e.Int64() > maxConstBits/int64(v.BitLen()) {
+			return nil, false
+		}
+		return new(big.Int).Exp(v, e, nil), true
+
+	case *ast.Unary:
+		switch {
+		case n.Value != nil:
+			return ConstInt(n.Value, consts)
+		case n.Negated != nil:
+			v, ok := ConstInt(n.Negated, consts)
+			if ok {
+				v = new(big.Int).Neg(v)
+			}
+			return v, ok
+		}
+
+	case *ast.Primary:
+		switch {
+		case n.BasicLit != nil && n.BasicLit.IntLit != nil:
+			return big.NewInt(int64(*n.BasicLit.IntLit)), true
+		case n.Variable != nil && len(n.Variable.Indices) == 0:
+			v, ok := consts[n.Variable.Ident]
+			return v, ok
+		case n.SubExpr != nil:
+			return ConstInt(n.SubExpr, consts)
+		}
+	}
+	return nil, false
+}
+
+// constOp applies the integer operator op to l and r. Division truncates
+// toward zero, as in scanspec.
+func constOp(op ast.Operator, l, r *big.Int) (*big.Int, bool) {
+	z := new(big.Int)
+	switch op {
+	case "+":
+		z.Add(l, r)
+	case "-":
+		z.Sub(l, r)
+	case "*":
+		z.Mul(l, r)
+	case "/", "%":
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		if op == "/" {
+			z.Quo(l, r)
+		} else {
+			z.Rem(l, r)
+		}
+	case "&":
+		z.And(l, r)
+	case "|":
+		z.Or(l, r)
+	case "xor":
+		z.Xor(l, r)
+	case "<<", ">>":
+		if r.Sign() < 0 || !r.IsInt64() || op == "<<" && r.Int64() > maxConstBits {
+			return nil, false
+		}
+		if op == "<<" {
+			z.Lsh(l, uint(r.Int64()))
+		} else {
+			z.Rsh(l, uint(r.Int64()))
+		}
+	default:
+		return nil, false
+	}
+	return z, z.BitLen() <= maxConstBits
+}
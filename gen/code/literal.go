@@ -0,0 +1,18 @@
+// Copyright 2020 Furqan Software Ltd. All rights reserved.
+
+package code
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatFloat returns the shortest spelling of f that C++, Go and Python all
+// read back as a floating-point literal.
+func FormatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 Furqan Software Ltd. All rights reserved.
+
+package code
+
+import (
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"git.furqansoftware.net/toph/scanlib/eval"
+)
+
+// FunctionType maps the built-in functions that can be generated to the
+// scanspec types of their results.
+var FunctionType = map[string]string{
+	"len":          "int",
+	"isLower":      "bool",
+	"isUpper":      "bool",
+	"isDigits":     "bool",
+	"onlyChars":    "bool",
+	"countChar":    "int",
+	"contains":     "bool",
+	"hasPrefix":    "bool",
+	"hasSuffix":    "bool",
+	"upper":        "string",
+	"lower":        "string",
+	"isPalindrome": "bool",
+}
+
+// Promote returns the type operands of types a and b are converted to before
+// a binary operation, as eval.Promote gives it. It returns "" if either type
+// is unknown or they cannot be combined.
+func Promote(a, b string) string {
+	t, ok := eval.Promote(a, b)
+	if !ok {
+		return ""
+	}
+	return t
+}
+
+// IsUntyped reports whether t is the type of an untyped constant.
+func IsUntyped(t string) bool {
+	return t == eval.UntypedInt || t == eval.UntypedFloat
+}
+
+// IsInteger reports whether t is an integer type. Operations on integers
+// divide as integers do.
+func IsInteger(t string) bool {
+	switch t {
+	case "char", "int", "int64", "uint32", "uint64", "bigint", eval.UntypedInt:
+		return true
+	}
+	return false
+}
+
+// ExprType infers the scanspec type of n following the promotion rules of
+// package eval, with varType giving the type of each variable. It returns ""
+// if the type is unknown.
+func ExprType(n ast.Node, varType func(*ast.Variable) string) string {
+	switch n := n.(type) {
+	case *ast.Expr:
+		if len(n.Right) > 0 {
+			return "bool"
+		}
+		return ExprType(n.Left, varType)
+
+	case *ast.LogicalOr:
+		if len(n.Right) > 0 {
+			return "bool"
+		}
+		return ExprType(n.Left, varType)
+
+	case *ast.LogicalAnd:
+		if len(n.Right) > 0 || n.Interval != nil {
+			return "bool"
+		}
+		return ExprType(n.Left, varType)
+
+	case *ast.Relative:
+		t := ExprType(n.Left, varType)
+		for _, c := range n.Right {
+			t = Promote(t, ExprType(c.Addition, varType))
+		}
+		return t
+
+	case *ast.Addition:
+		t := ExprType(n.Left, varType)
+		for _, c := range n.Right {
+			t = Promote(t, ExprType(c.Factor, varType))
+		}
+		return t
+
+	case *ast.Multiplication:
+		t := ExprType(n.Unary, varType)
+		if n.Exponent != nil {
+			t = Promote(t, ExprType(n.Exponent, varType))
+		}
+		return t
+
+	case *ast.Unary:
+		switch {
+		case n.Value != nil:
+			return ExprType(n.Value, varType)
+		case n.Negated != nil:
+			return ExprType(n.Negated, varType)
+		case n.Not != nil:
+			return "bool"
+		}
+
+	case *ast.Primary:
+		switch {
+		case n.BasicLit != nil:
+			switch {
+			case n.BasicLit.IntLit != nil:
+				return eval.UntypedInt
+			case n.BasicLit.FloatLit != nil:
+				return eval.UntypedFloat
+			case n.BasicLit.StringLit != nil:
+				return "string"
+			case n.BasicLit.CharLit != nil:
+				return "char"
+			case n.BasicLit.BoolLit != nil:
+				return "bool"
+			}
+		case n.Conversion != nil:
+			return n.Conversion.Type
+		case n.Quantifier != nil:
+			return "bool"
+		case n.CallExpr != nil:
+			return FunctionType[n.CallExpr.Ident]
+		case n.Variable != nil:
+			return varType(n.Variable)
+		case n.SubExpr != nil:
+			return ExprType(n.SubExpr, varType)
+		}
+	}
+	return ""
+}
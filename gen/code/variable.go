@@ -0,0 +1,55 @@
+// Copyright 2020 Furqan Software Ltd. All rights reserved.
+
+package code
+
+import "git.furqansoftware.net/toph/scanlib/ast"
+
+// SoleVariable returns the variable n consists of, possibly parenthesized, or
+// nil if n is any other expression.
+func SoleVariable(n ast.Node) *ast.Variable {
+	for {
+		switch m := n.(type) {
+		case *ast.Expr:
+			if len(m.Right) > 0 {
+				return nil
+			}
+			n = m.Left
+		case *ast.LogicalOr:
+			if len(m.Right) > 0 {
+				return nil
+			}
+			n = m.Left
+		case *ast.LogicalAnd:
+			if len(m.Right) > 0 || m.Interval != nil {
+				return nil
+			}
+			n = m.Left
+		case *ast.Relative:
+			if len(m.Right) > 0 {
+				return nil
+			}
+			n = m.Left
+		case *ast.Addition:
+			if len(m.Right) > 0 {
+				return nil
+			}
+			n = m.Left
+		case *ast.Multiplication:
+			if m.Exponent != nil || m.Unary.Value == nil {
+				return nil
+			}
+			n = m.Unary.Value
+		case *ast.Primary:
+			switch {
+			case m.Variable != nil:
+				return m.Variable
+			case m.SubExpr != nil:
+				n = m.SubExpr
+			default:
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -2,10 +2,19 @@
 
 package cpp14
 
-import "git.furqansoftware.net/toph/scanlib/gen/code"
+import (
+	"math/big"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"git.furqansoftware.net/toph/scanlib/gen/code"
+)
 
 type Context struct {
-	types    map[string]string
-	includes map[string]bool
-	cw       *code.Writer
+	types       map[string]string
+	dims        map[string][]*ast.Expr
+	includes    map[string]bool
+	consts      *code.Writer
+	constValues map[string]*big.Int
+	helpers     map[string]bool
+	cw          *code.Writer
 }
@@ -5,7 +5,11 @@ package cpp14
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 
 	"git.furqansoftware.net/toph/scanlib/ast"
 	"git.furqansoftware.net/toph/scanlib/gen/code"
@@ -13,13 +17,18 @@ import (
 
 type Generator struct {
 	ctx *Context
+	err error
 }
 
 func Generate(n *ast.Source) ([]byte, error) {
 	ctx := Context{
-		types:    map[string]string{},
-		includes: map[string]bool{},
-		cw:       code.NewWriter("\t"),
+		types:       map[string]string{},
+		dims:        map[string][]*ast.Expr{},
+		includes:    map[string]bool{},
+		consts:      code.NewWriter("\t"),
+		constValues: map[string]*big.Int{},
+		helpers:     map[string]bool{},
+		cw:          code.NewWriter("\t"),
 	}
 	ctx.includes["iostream"] = true
 
@@ -30,6 +39,9 @@ func Generate(n *ast.Source) ([]byte, error) {
 	ctx.cw.Indent(1)
 	ast.Walk(&g, n)
 	ctx.cw.Indent(-1)
+	if g.err != nil {
+		return nil, g.err
+	}
 
 	r := bytes.Buffer{}
 	includes := []string{}
@@ -43,6 +55,19 @@ func Generate(n *ast.Source) ([]byte, error) {
 	r.WriteString("\n")
 	r.WriteString("using namespace std;\n")
 	r.WriteString("\n")
+	helpers := []string{}
+	for k := range ctx.helpers {
+		helpers = append(helpers, k)
+	}
+	sort.Strings(helpers)
+	for _, h := range helpers {
+		r.WriteString(helperDefs[h])
+		r.WriteString("\n")
+	}
+	if ctx.consts.Len() > 0 {
+		r.Write(ctx.consts.Bytes())
+		r.WriteString("\n")
+	}
 	r.WriteString("int main() {\n")
 	r.Write(ctx.cw.Bytes())
 	r.WriteString("\t\n")
@@ -53,41 +78,87 @@ func Generate(n *ast.Source) ([]byte, error) {
 }
 
 func (g *Generator) Visit(n ast.Node) (w ast.Visitor) {
-	if n == nil {
+	if n == nil || g.err != nil {
 		return nil
 	}
 
 	switch n := n.(type) {
-	case *ast.Source, *ast.Block, *ast.Statement:
+	case *ast.Source, *ast.Block, *ast.Statement, *ast.SubtaskStmt:
 		return g
 
-	case *ast.CheckStmt, *ast.EOLStmt, *ast.EOFStmt:
+	case *ast.LetDecl, *ast.CheckStmt, *ast.EOLStmt, *ast.EOFStmt:
+		return nil
+
+	case *ast.ConstDecl:
+		g.fail(g.constDecl(n))
 		return nil
 
 	case *ast.VarDecl:
-		g.varDecl(n)
+		g.fail(g.varDecl(n))
 		return nil
 
 	case *ast.ScanStmt:
-		g.scanStmt(n, false)
+		g.fail(g.scanStmt(n, false))
 		return nil
 
 	case *ast.ScanlnStmt:
-		g.scanlnStmt(n, false)
+		g.fail(g.scanlnStmt(n, false))
 		return nil
 
 	case *ast.IfStmt:
-		g.ifStmt(n)
+		g.fail(g.ifStmt(n))
 		return nil
 
 	case *ast.ForStmt:
-		g.forStmt(n)
+		g.fail(g.forStmt(n))
 		return nil
 	}
 
 	panic(fmt.Errorf("unreachable, with %T", n))
 }
 
+// fail records err, unless an error is already recorded. Generate returns the
+// first error recorded.
+func (g *Generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+func (g *Generator) constDecl(n *ast.ConstDecl) error {
+	cw := g.ctx.cw
+	defer func() { g.ctx.cw = cw }()
+	g.ctx.cw = code.NewWriter("\t")
+	err := genExpr(g.ctx, &n.ConstSpec.Value)
+	if err != nil {
+		return err
+	}
+	v := string(g.ctx.cw.Bytes())
+	if !isOperand(&n.ConstSpec.Value) {
+		v = "(" + v + ")"
+	}
+	if c, ok := code.ConstInt(&n.ConstSpec.Value, g.ctx.constValues); ok {
+		g.ctx.constValues[n.ConstSpec.Ident] = c
+	}
+	g.ctx.types[n.ConstSpec.Ident] = exprType(g.ctx, &n.ConstSpec.Value)
+	g.ctx.consts.Printf("#define %s %s", n.ConstSpec.Ident, v)
+	g.ctx.consts.Println()
+	return nil
+}
+
+// isOperand reports whether n is generated as a single operand, which needs no
+// parentheses when substituted into another expression.
+func isOperand(n *ast.Expr) bool {
+	if len(n.Right) > 0 || len(n.Left.Right) > 0 {
+		return false
+	}
+	l := n.Left.Left
+	if len(l.Right) > 0 || l.Interval != nil || len(l.Left.Right) > 0 || len(l.Left.Left.Right) > 0 {
+		return false
+	}
+	return l.Left.Left.Left.Unary.Value != nil
+}
+
 func (g *Generator) varDecl(n *ast.VarDecl) error {
 	switch {
 	case n.VarSpec.Type.TypeName != nil:
@@ -98,7 +169,7 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 
 		g.ctx.cw.Printf("%s", t)
 		for i, x := range n.VarSpec.IdentList {
-			g.ctx.types[x] = t
+			g.ctx.types[x] = *n.VarSpec.Type.TypeName
 
 			if i > 0 {
 				g.ctx.cw.Printf(",")
@@ -108,25 +179,32 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 		g.ctx.cw.Println(";")
 
 	case n.VarSpec.Type.TypeLit != nil:
-		t := ASTType[*n.VarSpec.Type.TypeLit.ArrayType.ElementType.TypeName]
+		dims, et := n.VarSpec.Type.TypeLit.ArrayType.Dims()
+		t := ASTType[et]
 		if t == "string" {
 			g.ctx.includes["string"] = true
 		}
 
 		g.ctx.cw.Printf("%s", t)
 		for i, x := range n.VarSpec.IdentList {
-			g.ctx.types[x] = "array"
-			g.ctx.types[x+"[]"] = t
+			for k := range dims {
+				g.ctx.types[x+strings.Repeat("[]", k)] = "array"
+			}
+			g.ctx.types[x+strings.Repeat("[]", len(dims))] = et
+			g.ctx.dims[x] = dims
 
 			if i > 0 {
 				g.ctx.cw.Printf(",")
 			}
-			g.ctx.cw.Printf(" %s[", x)
-			err := genExpr(g.ctx, &n.VarSpec.Type.TypeLit.ArrayType.ArrayLength)
-			if err != nil {
-				return err
+			g.ctx.cw.Printf(" %s", x)
+			for _, d := range dims {
+				g.ctx.cw.Print("[")
+				err := genExpr(g.ctx, d)
+				if err != nil {
+					return err
+				}
+				g.ctx.cw.Print("]")
 			}
-			g.ctx.cw.Print("]")
 		}
 		g.ctx.cw.Println(";")
 	}
@@ -134,22 +212,196 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 }
 
 func (g *Generator) scanStmt(n *ast.ScanStmt, asexpr bool) error {
+	if (n.Sep != "" || n.Width != nil) && !asexpr {
+		g.ctx.cw.Println("{")
+		g.ctx.cw.Indent(1)
+		g.ctx.cw.Println("string _t;")
+		g.ctx.cw.Println("cin >> _t;")
+		err := g.scanFields(n)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Indent(-1)
+		g.ctx.cw.Println("}")
+		return nil
+	}
+
+	if !asexpr && (hasCharRow(g.ctx, n) || hasSlice(n)) {
+		return g.scanEach(n)
+	}
+
 	g.ctx.cw.Print("cin")
 	for _, f := range n.RefList {
-		g.ctx.cw.Printf(" >> %s", f.Ident)
-		for _, i := range f.Indices {
-			g.ctx.cw.Print("[")
-			err := genExpr(g.ctx, &i)
+		g.ctx.cw.Print(" >> ")
+		err := genRef(g.ctx, &f)
+		if err != nil {
+			return err
+		}
+	}
+	if !asexpr {
+		g.ctx.cw.Print(";")
+		g.ctx.cw.Println()
+	}
+	return nil
+}
+
+// scanEach scans the references of n one at a time, looping over slices of
+// arrays and reading rows of char arrays a character at a time.
+func (g *Generator) scanEach(n *ast.ScanStmt) error {
+	for _, f := range n.RefList {
+		if f.High != nil {
+			err := genSliceLoop(g.ctx, &f)
 			if err != nil {
 				return err
 			}
-			g.ctx.cw.Print("]")
 		}
+		row := isCharRow(g.ctx, &f)
+		if row {
+			g.ctx.cw.Print("for (int _j = 0; _j < ")
+			err := genExpr(g.ctx, g.ctx.dims[f.Ident][len(f.Indices)])
+			if err != nil {
+				return err
+			}
+			g.ctx.cw.Print("; ++_j) ")
+		}
+		g.ctx.cw.Print("cin >> ")
+		err := genRef(g.ctx, &f)
+		if err != nil {
+			return err
+		}
+		if row {
+			g.ctx.cw.Print("[_j]")
+		}
+		g.ctx.cw.Println(";")
 	}
-	if !asexpr {
-		g.ctx.cw.Print(";")
+	return nil
+}
+
+func hasSlice(n *ast.ScanStmt) bool {
+	for _, f := range n.RefList {
+		if f.High != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// genRef emits the variable or array element f refers to. For a slice of an
+// array, it emits the element at _k, the index of the loop genSliceLoop emits.
+func genRef(ctx *Context, f *ast.Reference) error {
+	ctx.cw.Print(f.Ident)
+	indices := f.Indices
+	if f.High != nil {
+		indices = indices[:len(indices)-1]
+	}
+	for i := range indices {
+		ctx.cw.Print("[")
+		err := genExpr(ctx, &indices[i])
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print("]")
+	}
+	if f.High != nil {
+		ctx.cw.Print("[_k]")
+	}
+	return nil
+}
+
+// genSliceLoop emits the head of a loop over the elements of the slice of an
+// array f refers to.
+func genSliceLoop(ctx *Context, f *ast.Reference) error {
+	ctx.cw.Print("for (int _k = ")
+	err := genExpr(ctx, &f.Indices[len(f.Indices)-1])
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print("; _k < ")
+	err = genExpr(ctx, f.High)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print("; ++_k) ")
+	return nil
+}
+
+// isCharRow reports whether f refers to a row of a char array, which is
+// scanned from a single token.
+func isCharRow(ctx *Context, f *ast.Reference) bool {
+	k := f.Ident + strings.Repeat("[]", len(f.Indices))
+	return ctx.types[k] == "array" && ctx.types[k+"[]"] == "char"
+}
+
+func hasCharRow(ctx *Context, n *ast.ScanStmt) bool {
+	for _, f := range n.RefList {
+		if isCharRow(ctx, &f) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanFields reads the fields of n from the token in _t, split by the
+// separator or the width of n.
+func (g *Generator) scanFields(n *ast.ScanStmt) error {
+	g.ctx.includes["sstream"] = true
+	g.ctx.includes["string"] = true
+	if n.Sep != "" {
+		g.ctx.cw.Println("istringstream _ss(_t);")
+		g.ctx.cw.Println("string _f;")
+	}
+	for _, f := range n.RefList {
+		if f.High != nil {
+			err := genSliceLoop(g.ctx, &f)
+			if err != nil {
+				return err
+			}
+			g.ctx.cw.Println("{")
+			g.ctx.cw.Indent(1)
+		}
+		err := g.scanField(n, &f)
+		if err != nil {
+			return err
+		}
+		if f.High != nil {
+			g.ctx.cw.Indent(-1)
+			g.ctx.cw.Println("}")
+		}
+	}
+	return nil
+}
+
+// scanField reads the next field of n from the token in _t into f.
+func (g *Generator) scanField(n *ast.ScanStmt, f *ast.Reference) error {
+	if n.Sep != "" {
+		g.ctx.cw.Printf("getline(_ss, _f, %s);", strconv.QuoteRuneToASCII(rune(n.Sep[0])))
 		g.ctx.cw.Println()
+		g.ctx.cw.Print("istringstream(_f) >> ")
+		err := genRef(g.ctx, f)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Println(";")
+		return nil
+	}
+
+	g.ctx.cw.Print("istringstream(_t.substr(0, ")
+	err := genExpr(g.ctx, n.Width)
+	if err != nil {
+		return err
 	}
+	g.ctx.cw.Print(")) >> ")
+	err = genRef(g.ctx, f)
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Println(";")
+	g.ctx.cw.Print("_t.erase(0, ")
+	err = genExpr(g.ctx, n.Width)
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Println(");")
 	return nil
 }
 
@@ -234,11 +486,20 @@ func (g *Generator) forRangeStmt(n *ast.ForStmt) error {
 }
 
 func (g *Generator) forScanStmt(n *ast.ForStmt) error {
-	g.ctx.cw.Print("while (")
-	g.scanStmt(n.Scan, true)
-	g.ctx.cw.Print(") {")
-	g.ctx.cw.Println()
-	g.ctx.cw.Indent(1)
+	if n.Scan.Sep != "" || n.Scan.Width != nil {
+		g.ctx.cw.Println("for (string _t; cin >> _t; ) {")
+		g.ctx.cw.Indent(1)
+		err := g.scanFields(n.Scan)
+		if err != nil {
+			return err
+		}
+	} else {
+		g.ctx.cw.Print("while (")
+		g.scanStmt(n.Scan, true)
+		g.ctx.cw.Print(") {")
+		g.ctx.cw.Println()
+		g.ctx.cw.Indent(1)
+	}
 	ast.Walk(g, &n.Block)
 	g.ctx.cw.Indent(-1)
 	g.ctx.cw.Print("}")
@@ -293,25 +554,143 @@ func genOpLogicalOr(ctx *Context, n *ast.OpLogicalOr) error {
 }
 
 func genLogicalAnd(ctx *Context, n *ast.LogicalAnd) error {
-	err := genRelative(ctx, n.Left)
+	if n.Interval != nil {
+		return genInterval(ctx, n.Left, n.Interval)
+	}
+	if len(n.Right) == 0 {
+		return genRelative(ctx, n.Left)
+	}
+	l := n.Left
+	for i, c := range n.Right {
+		if i > 0 {
+			// Comparisons chain: a < b < c is emitted as a < b && b < c.
+			ctx.cw.Print("&&")
+		}
+		err := genComparison(ctx, l, string(c.Operator), c.Relative)
+		if err != nil {
+			return err
+		}
+		l = c.Relative
+	}
+	return nil
+}
+
+func genInterval(ctx *Context, v *ast.Relative, n *ast.Interval) error {
+	op := ">"
+	if n.LowClosed {
+		op = ">="
+	}
+	err := genComparison(ctx, v, op, &n.Low)
 	if err != nil {
 		return err
 	}
-	for _, c := range n.Right {
-		err := genOpRelative(ctx, c)
+	ctx.cw.Print("&&")
+	op = "<"
+	if n.HighClosed {
+		op = "<="
+	}
+	return genComparison(ctx, v, op, &n.High)
+}
+
+// genComparison emits l op r. Bigints, held as strings, are compared with the
+// helper cmp_bigint.
+func genComparison(ctx *Context, l ast.Node, op string, r ast.Node) error {
+	if !isBig(ctx, l) && !isBig(ctx, r) {
+		err := genNode(ctx, l)
 		if err != nil {
 			return err
 		}
+		ctx.cw.Print(op)
+		return genNode(ctx, r)
 	}
+	ctx.helpers["cmp_bigint"] = true
+	ctx.cw.Print("cmp_bigint(")
+	err := genBig(ctx, l)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(", ")
+	err = genBig(ctx, r)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf(")%s0", op)
 	return nil
 }
 
+// isBig reports whether n is a bigint variable.
+func isBig(ctx *Context, n ast.Node) bool {
+	v := code.SoleVariable(n)
+	return v != nil && variableType(ctx, v) == "bigint"
+}
+
+// genBig emits n as the decimal string of a bigint. Besides bigint variables,
+// n may be an integer constant or a variable of an integer type.
+func genBig(ctx *Context, n ast.Node) error {
+	if v, ok := code.ConstInt(n, ctx.constValues); ok {
+		ctx.cw.Printf("%q", v.String())
+		return nil
+	}
+	v := code.SoleVariable(n)
+	if v == nil {
+		return errBigint
+	}
+	switch variableType(ctx, v) {
+	case "bigint":
+		return genElement(ctx, v)
+	case "int", "int64", "uint32", "uint64":
+		ctx.cw.Print("to_string(")
+		err := genElement(ctx, v)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
+	}
+	return errBigint
+}
+
+// exprType infers the scanspec type of n following the promotion rules of
+// package eval. It returns "" if the type is unknown.
+func exprType(ctx *Context, n ast.Node) string {
+	return code.ExprType(n, func(v *ast.Variable) string {
+		return variableType(ctx, v)
+	})
+}
+
+// truncates reports whether a value of type from is cast to be used as type
+// to, as a constant such as 2.0 is in an integer operation.
+func truncates(from, to string) bool {
+	return code.IsInteger(to) && !code.IsInteger(from)
+}
+
+// variableType returns the scanspec type of n, or "" if n is a string sliced
+// or indexed.
+func variableType(ctx *Context, n *ast.Variable) string {
+	return ctx.types[n.Ident+strings.Repeat("[]", len(n.Indices))]
+}
+
+func genNode(ctx *Context, n ast.Node) error {
+	switch n := n.(type) {
+	case *ast.Expr:
+		return genExpr(ctx, n)
+	case *ast.Relative:
+		return genRelative(ctx, n)
+	}
+	panic(fmt.Errorf("unreachable, with %T", n))
+}
+
 func genOpLogicalAnd(ctx *Context, n *ast.OpLogicalAnd) error {
 	ctx.cw.Print("&&")
 	return genLogicalAnd(ctx, n.LogicalAnd)
 }
 
 func genRelative(ctx *Context, n *ast.Relative) error {
+	for _, c := range n.Right {
+		if parenOperators[c.Operator] {
+			ctx.cw.Print("(")
+		}
+	}
 	err := genAddition(ctx, n.Left)
 	if err != nil {
 		return err
@@ -325,18 +704,31 @@ func genRelative(ctx *Context, n *ast.Relative) error {
 	return nil
 }
 
-func genOpRelative(ctx *Context, n *ast.OpRelative) error {
-	ctx.cw.Print(string(n.Operator))
-	return genRelative(ctx, n.Relative)
-}
-
+// genAddition emits the left-associative operation n. Wherever an operation
+// is on integers, as package eval promotes its operands, operands that are not
+// are cast to its type, so that division truncates.
 func genAddition(ctx *Context, n *ast.Addition) error {
+	types := []string{exprType(ctx, n.Left)}
+	for _, c := range n.Right {
+		types = append(types, code.Promote(types[len(types)-1], exprType(ctx, c.Factor)))
+	}
+	for i := len(n.Right) - 1; i >= 0; i-- {
+		if parenOperators[n.Right[i].Operator] {
+			ctx.cw.Print("(")
+		}
+		if truncates(types[i], types[i+1]) {
+			ctx.cw.Printf("static_cast<%s>(", ASTType[types[i+1]])
+		}
+	}
 	err := genMultiplication(ctx, n.Left)
 	if err != nil {
 		return err
 	}
-	for _, c := range n.Right {
-		err := genOpMultiplication(ctx, c)
+	for i, c := range n.Right {
+		if truncates(types[i], types[i+1]) {
+			ctx.cw.Print(")")
+		}
+		err := genOpMultiplication(ctx, c, types[i+1])
 		if err != nil {
 			return err
 		}
@@ -345,17 +737,76 @@ func genAddition(ctx *Context, n *ast.Addition) error {
 }
 
 func genOpAddition(ctx *Context, n *ast.OpAddition) error {
-	ctx.cw.Print(string(n.Operator))
-	return genAddition(ctx, n.Addition)
+	ctx.cw.Print(ASTOperator[n.Operator])
+	err := genAddition(ctx, n.Addition)
+	if err != nil {
+		return err
+	}
+	if parenOperators[n.Operator] {
+		ctx.cw.Print(")")
+	}
+	return nil
 }
 
 func genMultiplication(ctx *Context, n *ast.Multiplication) error {
-	return genUnary(ctx, n.Unary)
+	if n.Exponent == nil {
+		return genUnary(ctx, n.Unary)
+	}
+	if v, ok := code.ConstInt(n, ctx.constValues); ok && intLiteral(v) != "" {
+		ctx.cw.Print(intLiteral(v))
+		return nil
+	}
+	ctx.includes["cmath"] = true
+	ctx.cw.Print("pow(")
+	err := genUnary(ctx, n.Unary)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(", ")
+	err = genPrimary(ctx, n.Exponent)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
+}
+
+// intLiteral spells v as an integer literal of the narrowest of long long and
+// unsigned long long that holds it, or returns "" if neither does. The
+// literal for the least long long would overflow before it is negated.
+func intLiteral(v *big.Int) string {
+	switch {
+	case v.IsInt64() && v.Int64() == math.MinInt64:
+		return ""
+	case v.IsInt64() && v.Sign() < 0:
+		return "(" + v.String() + ")"
+	case v.IsInt64():
+		return v.String()
+	case v.IsUint64():
+		return v.String() + "ULL"
+	}
+	return ""
 }
 
-func genOpMultiplication(ctx *Context, n *ast.OpMultiplication) error {
-	ctx.cw.Print(string(n.Operator))
-	return genMultiplication(ctx, n.Factor)
+// genOpMultiplication emits the operator and right operand of n, an operation
+// on type t.
+func genOpMultiplication(ctx *Context, n *ast.OpMultiplication, t string) error {
+	ctx.cw.Print(ASTOperator[n.Operator])
+	conv := truncates(exprType(ctx, n.Factor), t)
+	if conv {
+		ctx.cw.Printf("static_cast<%s>(", ASTType[t])
+	}
+	err := genMultiplication(ctx, n.Factor)
+	if err != nil {
+		return err
+	}
+	if conv {
+		ctx.cw.Print(")")
+	}
+	if parenOperators[n.Operator] {
+		ctx.cw.Print(")")
+	}
+	return nil
 }
 
 func genUnary(ctx *Context, n *ast.Unary) error {
@@ -366,53 +817,192 @@ func genUnary(ctx *Context, n *ast.Unary) error {
 	case n.Negated != nil:
 		ctx.cw.Print("-")
 		return genPrimary(ctx, n.Negated)
+	case n.Not != nil:
+		ctx.cw.Print("!")
+		return genPrimary(ctx, n.Not)
 	}
 	panic("unreachable")
 }
 
 func genPrimary(ctx *Context, n *ast.Primary) error {
 	switch {
+	case n.Conversion != nil:
+		ctx.cw.Printf("static_cast<%s>(", ASTType[n.Conversion.Type])
+		err := genExpr(ctx, &n.Conversion.Expr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
+
+	case n.Quantifier != nil:
+		return genQuantifier(ctx, n.Quantifier)
+
 	case n.CallExpr != nil:
-		// args := []interface{}{}
-		// for _, a := range n.Call.Arguments {
-		// 	v, err := genExpr(ctx, &a)
-		// 	if err != nil {
-		// 		return err
-		// 	}
-		// 	args = append(args, v)
-		// }
-		// return Functions[n.Call.Name](args...)
+		return genCall(ctx, n.CallExpr)
 
 	case n.Variable != nil:
-		// indices := []int{}
-		// for _, i := range n.Variable.Indices {
-		// 	v, err := genExpr(ctx, &i)
-		// 	if err != nil {
-		// 		return err
-		// 	}
-		// 	vi, ok := v.(int)
-		// 	if !ok {
-		// 		return nil, ErrNonIntegerIndex{}
-		// 	}
-		// 	indices = append(indices, vi)
-		// }
-		// return ctx.GetValue(n.Variable.Identifier, indices).Data, nil
-		ctx.cw.Print(n.Variable.Ident)
-		return nil
+		return genVariable(ctx, n.Variable)
 
 	case n.BasicLit != nil:
 		return genBasicLit(ctx, n.BasicLit)
 
 	case n.SubExpr != nil:
-		return genExpr(ctx, n.SubExpr)
+		ctx.cw.Print("(")
+		err := genExpr(ctx, n.SubExpr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
 	}
 	panic("unreachable")
 }
 
+// genCall emits the call to a built-in function n as ASTFunction spells it. The
+// length of an array is the expression it was declared with.
+func genCall(ctx *Context, n *ast.CallExpr) error {
+	if n.Ident == "len" && len(n.Args) == 1 {
+		v := code.SoleVariable(&n.Args[0])
+		if v != nil && variableType(ctx, v) == "array" {
+			return genOperand(ctx, ctx.dims[v.Ident][len(v.Indices)])
+		}
+	}
+	f, ok := ASTFunction[n.Ident]
+	if !ok {
+		return fmt.Errorf("cpp14: built-in function %s cannot be generated", n.Ident)
+	}
+	if i := strings.Index(f, "("); i > 0 {
+		if _, ok := helperDefs[f[:i]]; ok {
+			ctx.helpers[f[:i]] = true
+		}
+	}
+	ctx.includes["string"] = true
+	cw := ctx.cw
+	defer func() { ctx.cw = cw }()
+	args := make([]interface{}, len(n.Args))
+	for i := range n.Args {
+		ctx.cw = code.NewWriter("\t")
+		err := genOperand(ctx, &n.Args[i])
+		if err != nil {
+			return err
+		}
+		args[i] = string(ctx.cw.Bytes())
+	}
+	cw.Printf(f, args...)
+	return nil
+}
+
+// genQuantifier emits n as a lambda, called in place, that loops over the range
+// of n until the outcome is known.
+func genQuantifier(ctx *Context, n *ast.Quantifier) error {
+	ctx.cw.Println("[&] {")
+	ctx.cw.Indent(1)
+	ctx.cw.Printf("for (int %s = ", n.Index)
+	err := genExpr(ctx, &n.Low)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf("; %s < ", n.Index)
+	err = genExpr(ctx, &n.High)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf("; ++%s) {", n.Index)
+	ctx.cw.Println()
+	ctx.cw.Indent(1)
+	all := n.Kind == "all"
+	if all {
+		ctx.cw.Print("if (!(")
+	} else {
+		ctx.cw.Print("if (")
+	}
+	err = genExpr(ctx, &n.Expr)
+	if err != nil {
+		return err
+	}
+	if all {
+		ctx.cw.Print(")")
+	}
+	ctx.cw.Println(") {")
+	ctx.cw.Indent(1)
+	ctx.cw.Printf("return %t;", !all)
+	ctx.cw.Println()
+	ctx.cw.Indent(-1)
+	ctx.cw.Println("}")
+	ctx.cw.Indent(-1)
+	ctx.cw.Println("}")
+	ctx.cw.Printf("return %t;", all)
+	ctx.cw.Println()
+	ctx.cw.Indent(-1)
+	ctx.cw.Print("}()")
+	return nil
+}
+
+// genVariable emits n with its indices. A slice becomes a call to substr,
+// which takes a length rather than the high end.
+func genVariable(ctx *Context, n *ast.Variable) error {
+	if variableType(ctx, n) == "bigint" {
+		return errBigint
+	}
+	return genElement(ctx, n)
+}
+
+func genElement(ctx *Context, n *ast.Variable) error {
+	ctx.cw.Print(n.Ident)
+	indices := n.Indices
+	if n.High != nil {
+		indices = indices[:len(indices)-1]
+	}
+	for i := range indices {
+		ctx.cw.Print("[")
+		err := genExpr(ctx, &indices[i])
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print("]")
+	}
+	if n.High == nil {
+		return nil
+	}
+	low := &n.Indices[len(indices)]
+	ctx.cw.Print(".substr(")
+	err := genExpr(ctx, low)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(", ")
+	err = genOperand(ctx, n.High)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print("-")
+	err = genOperand(ctx, low)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
+}
+
+// genOperand emits n, parenthesised unless it is a single operand.
+func genOperand(ctx *Context, n *ast.Expr) error {
+	if isOperand(n) {
+		return genExpr(ctx, n)
+	}
+	ctx.cw.Print("(")
+	err := genExpr(ctx, n)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
+}
+
 func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	switch {
 	case n.FloatLit != nil:
-		ctx.cw.Printf("%f", *n.FloatLit)
+		ctx.cw.Print(code.FormatFloat(*n.FloatLit))
 		return nil
 
 	case n.IntLit != nil:
@@ -422,6 +1012,14 @@ func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	case n.StringLit != nil:
 		ctx.cw.Printf("%q", *n.StringLit)
 		return nil
+
+	case n.CharLit != nil:
+		ctx.cw.Print(strconv.QuoteRuneToASCII(rune(*n.CharLit)))
+		return nil
+
+	case n.BoolLit != nil:
+		ctx.cw.Printf("%t", *n.BoolLit)
+		return nil
 	}
 
 	panic("unreachable")
@@ -1,10 +1,78 @@
 package cpp14
 
+import (
+	"errors"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
+
 var ASTType = map[string]string{
 	"bool":    "bool",
+	"char":    "char",
 	"int":     "int",
 	"int64":   "long long int",
+	"uint32":  "unsigned int",
+	"uint64":  "unsigned long long int",
+	"bigint":  "string",
 	"float32": "float",
 	"float64": "double",
+	"decimal": "double",
 	"string":  "string",
 }
+
+var ASTOperator = map[ast.Operator]string{
+	"+":   "+",
+	"-":   "-",
+	"|":   "|",
+	"xor": "^",
+	"*":   "*",
+	"/":   "/",
+	"%":   "%",
+	"<<":  "<<",
+	">>":  ">>",
+	"&":   "&",
+}
+
+// parenOperators lists the operators that bind differently here than in
+// scanspec. Operations using them are parenthesized in full.
+var parenOperators = map[ast.Operator]bool{
+	"|":   true,
+	"xor": true,
+	"<<":  true,
+	">>":  true,
+	"&":   true,
+}
+
+// errBigint is returned for a bigint operand of anything but a comparison, as
+// bigints are held as strings.
+var errBigint = errors.New("cpp14: bigint values can only be compared")
+
+// ASTFunction maps the built-in functions that can be generated to the format
+// of their calls, taking the arguments in order. Arguments that are called
+// methods on are parenthesized as needed.
+var ASTFunction = map[string]string{
+	"len":          "%s.size()",
+	"isLower":      `(%s.find_first_not_of("abcdefghijklmnopqrstuvwxyz") == string::npos)`,
+	"isUpper":      `(%s.find_first_not_of("ABCDEFGHIJKLMNOPQRSTUVWXYZ") == string::npos)`,
+	"isDigits":     `(%s.find_first_not_of("0123456789") == string::npos)`,
+	"onlyChars":    "(%s.find_first_not_of(%s) == string::npos)",
+	"countChar":    "count_char(%s, %s)",
+	"contains":     "(%s.find(%s) != string::npos)",
+	"hasPrefix":    "has_prefix(%s, %s)",
+	"hasSuffix":    "has_suffix(%s, %s)",
+	"upper":        "to_upper(%s)",
+	"lower":        "to_lower(%s)",
+	"isPalindrome": "is_palindrome(%s)",
+}
+
+// helperDefs holds the definitions of the functions that generated code calls.
+// cmp_bigint compares the decimal strings of two bigints as strcmp does.
+var helperDefs = map[string]string{
+	"count_char":    "int count_char(const string &s, char c) {\n\tint n = 0;\n\tfor (char d : s) {\n\t\tn += d == c;\n\t}\n\treturn n;\n}\n",
+	"has_prefix":    "bool has_prefix(const string &s, const string &t) {\n\treturn s.size() >= t.size() && s.compare(0, t.size(), t) == 0;\n}\n",
+	"has_suffix":    "bool has_suffix(const string &s, const string &t) {\n\treturn s.size() >= t.size() && s.compare(s.size() - t.size(), t.size(), t) == 0;\n}\n",
+	"is_palindrome": "bool is_palindrome(const string &s) {\n\treturn string(s.rbegin(), s.rend()) == s;\n}\n",
+	"to_lower":      "string to_lower(string s) {\n\tfor (char &c : s) {\n\t\tif ('A' <= c && c <= 'Z') {\n\t\t\tc += 'a' - 'A';\n\t\t}\n\t}\n\treturn s;\n}\n",
+	"to_upper":      "string to_upper(string s) {\n\tfor (char &c : s) {\n\t\tif ('a' <= c && c <= 'z') {\n\t\t\tc -= 'a' - 'A';\n\t\t}\n\t}\n\treturn s;\n}\n",
+	"cmp_bigint":    "int cmp_bigint(const string &a, const string &b) {\n\tbool neg = a[0] == '-';\n\tif (neg != (b[0] == '-')) {\n\t\treturn neg ? -1 : 1;\n\t}\n\tint c = a.size() == b.size() ? a.compare(b) : a.size() < b.size() ? -1 : 1;\n\treturn neg ? -c : c;\n}\n",
+}
@@ -2,10 +2,24 @@
 
 package go1
 
-import "git.furqansoftware.net/toph/scanlib/gen/code"
+import (
+	"math/big"
+
+	"git.furqansoftware.net/toph/scanlib/gen/code"
+)
 
 type Context struct {
-	types   map[string]string
-	imports map[string]bool
-	cw      *code.Writer
+	types       map[string]string
+	imports     map[string]bool
+	helpers     map[string]bool
+	consts      *code.Writer
+	constants   map[string]bool
+	constValues map[string]*big.Int
+	cw          *code.Writer
+
+	// The number of bigint constants declared in consts.
+	bigConsts int
+
+	// The type the untyped operands of the operation being emitted take.
+	untypedAs string
 }
@@ -5,21 +5,30 @@ package go1
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 
 	"git.furqansoftware.net/toph/scanlib/ast"
+	"git.furqansoftware.net/toph/scanlib/eval"
 	"git.furqansoftware.net/toph/scanlib/gen/code"
 )
 
 type Generator struct {
 	ctx *Context
+	err error
 }
 
 func Generate(n *ast.Source) ([]byte, error) {
 	ctx := Context{
-		types:   map[string]string{},
-		imports: map[string]bool{},
-		cw:      code.NewWriter("\t"),
+		types:       map[string]string{},
+		imports:     map[string]bool{},
+		helpers:     map[string]bool{},
+		consts:      code.NewWriter("\t"),
+		constants:   map[string]bool{},
+		constValues: map[string]*big.Int{},
+		cw:          code.NewWriter("\t"),
 	}
 
 	g := Generator{
@@ -29,6 +38,9 @@ func Generate(n *ast.Source) ([]byte, error) {
 	ctx.cw.Indent(1)
 	ast.Walk(&g, n)
 	ctx.cw.Indent(-1)
+	if g.err != nil {
+		return nil, g.err
+	}
 
 	r := bytes.Buffer{}
 	r.WriteString("package main\n")
@@ -49,6 +61,19 @@ func Generate(n *ast.Source) ([]byte, error) {
 		r.WriteString(")\n")
 		r.WriteString("\n")
 	}
+	if ctx.consts.Len() > 0 {
+		r.Write(ctx.consts.Bytes())
+		r.WriteString("\n")
+	}
+	helpers := []string{}
+	for k := range ctx.helpers {
+		helpers = append(helpers, k)
+	}
+	sort.Strings(helpers)
+	for _, h := range helpers {
+		r.WriteString(helperDefs[h])
+		r.WriteString("\n")
+	}
 	r.WriteString("func main() {\n")
 	r.Write(ctx.cw.Bytes())
 	r.WriteString("\t\n")
@@ -58,47 +83,91 @@ func Generate(n *ast.Source) ([]byte, error) {
 }
 
 func (g *Generator) Visit(n ast.Node) (w ast.Visitor) {
-	if n == nil {
+	if n == nil || g.err != nil {
 		return nil
 	}
 
 	switch n := n.(type) {
-	case *ast.Source, *ast.Block, *ast.Statement:
+	case *ast.Source, *ast.Block, *ast.Statement, *ast.SubtaskStmt:
 		return g
 
-	case *ast.CheckStmt, *ast.EOLStmt, *ast.EOFStmt:
+	case *ast.LetDecl, *ast.CheckStmt, *ast.EOLStmt, *ast.EOFStmt:
+		return nil
+
+	case *ast.ConstDecl:
+		g.fail(g.constDecl(n))
 		return nil
 
 	case *ast.VarDecl:
-		g.varDecl(n)
+		g.fail(g.varDecl(n))
 		return nil
 
 	case *ast.ScanStmt:
-		g.scanStmt(n, false)
+		g.fail(g.scanStmt(n, false))
 		return nil
 
 	case *ast.ScanlnStmt:
-		g.scanlnStmt(n, false)
+		g.fail(g.scanlnStmt(n, false))
 		return nil
 
 	case *ast.IfStmt:
-		g.ifStmt(n)
+		g.fail(g.ifStmt(n))
 		return nil
 
 	case *ast.ForStmt:
-		g.forStmt(n)
+		g.fail(g.forStmt(n))
 		return nil
 	}
 
 	panic(fmt.Errorf("unreachable, with %T", n))
 }
 
+// fail records err, unless an error is already recorded. Generate returns the
+// first error recorded.
+func (g *Generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+func (g *Generator) constDecl(n *ast.ConstDecl) error {
+	cw := g.ctx.cw
+	defer func() { g.ctx.cw = cw }()
+	g.ctx.cw = g.ctx.consts
+	if isConstExpr(g.ctx, &n.ConstSpec.Value) {
+		g.ctx.cw.Printf("const %s = ", n.ConstSpec.Ident)
+		g.ctx.constants[n.ConstSpec.Ident] = true
+		if v, ok := code.ConstInt(&n.ConstSpec.Value, g.ctx.constValues); ok {
+			g.ctx.constValues[n.ConstSpec.Ident] = v
+		}
+	} else {
+		g.ctx.cw.Printf("var %s = ", n.ConstSpec.Ident)
+	}
+	err := genExpr(g.ctx, &n.ConstSpec.Value)
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Println()
+	g.ctx.types[n.ConstSpec.Ident] = exprType(g.ctx, &n.ConstSpec.Value)
+	return nil
+}
+
 func (g *Generator) varDecl(n *ast.VarDecl) error {
+	if n.VarSpec.Type.TypeLit != nil {
+		dims, _ := n.VarSpec.Type.TypeLit.ArrayType.Dims()
+		if len(dims) > 1 {
+			return g.sliceDecl(n)
+		}
+	}
+
 	g.ctx.cw.Print("var")
 
 	switch {
 	case n.VarSpec.Type.TypeName != nil:
 		t := ASTType[*n.VarSpec.Type.TypeName]
+		if t == bigType {
+			g.ctx.imports["math/big"] = true
+		}
 
 		for i, x := range n.VarSpec.IdentList {
 			g.ctx.types[x] = t
@@ -108,11 +177,24 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 			}
 			g.ctx.cw.Printf(" %s", x)
 		}
-		g.ctx.cw.Printf(" %s", t)
+		if t == bigType {
+			g.ctx.cw.Print(" =")
+			for i := range n.VarSpec.IdentList {
+				if i > 0 {
+					g.ctx.cw.Printf(",")
+				}
+				g.ctx.cw.Print(" new(big.Int)")
+			}
+		} else {
+			g.ctx.cw.Printf(" %s", t)
+		}
 		g.ctx.cw.Println()
 
 	case n.VarSpec.Type.TypeLit != nil:
 		t := ASTType[*n.VarSpec.Type.TypeLit.ArrayType.ElementType.TypeName]
+		if t == bigType {
+			g.ctx.imports["math/big"] = true
+		}
 
 		for i, x := range n.VarSpec.IdentList {
 			g.ctx.types[x] = "array"
@@ -130,30 +212,310 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 		}
 		g.ctx.cw.Printf("]%s", t)
 		g.ctx.cw.Println()
+		if t == bigType {
+			for _, x := range n.VarSpec.IdentList {
+				g.allocBig(x, "_i")
+			}
+		}
+	}
+	return nil
+}
+
+// allocBig allocates each element of the array of *big.Int v, as the zero
+// value of a pointer is nil.
+func (g *Generator) allocBig(v, i string) {
+	g.ctx.cw.Printf("for %s := range %s {", i, v)
+	g.ctx.cw.Println()
+	g.ctx.cw.Indent(1)
+	g.ctx.cw.Printf("%s[%s] = new(big.Int)", v, i)
+	g.ctx.cw.Println()
+	g.ctx.cw.Indent(-1)
+	g.ctx.cw.Println("}")
+}
+
+// sliceDecl declares the multidimensional arrays of n as slices of slices.
+func (g *Generator) sliceDecl(n *ast.VarDecl) error {
+	dims, et := n.VarSpec.Type.TypeLit.ArrayType.Dims()
+	t := ASTType[et]
+	if t == bigType {
+		g.ctx.imports["math/big"] = true
+	}
+
+	for _, x := range n.VarSpec.IdentList {
+		for k := range dims {
+			g.ctx.types[x+strings.Repeat("[]", k)] = "array"
+		}
+		g.ctx.types[x+strings.Repeat("[]", len(dims))] = t
+
+		v, op := x, ":="
+		for k, d := range dims {
+			g.ctx.cw.Printf("%s %s make(%s%s, ", v, op, strings.Repeat("[]", len(dims)-k), t)
+			err := genExpr(g.ctx, d)
+			if err != nil {
+				return err
+			}
+			g.ctx.cw.Println(")")
+			if k == len(dims)-1 {
+				if t == bigType {
+					g.allocBig(v, fmt.Sprintf("_i%d", k))
+				}
+				break
+			}
+			i := fmt.Sprintf("_i%d", k)
+			g.ctx.cw.Printf("for %s := range %s {", i, v)
+			g.ctx.cw.Println()
+			g.ctx.cw.Indent(1)
+			v += "[" + i + "]"
+			op = "="
+		}
+		for k := 1; k < len(dims); k++ {
+			g.ctx.cw.Indent(-1)
+			g.ctx.cw.Println("}")
+		}
 	}
 	return nil
 }
 
 func (g *Generator) scanStmt(n *ast.ScanStmt, asexpr bool) error {
 	g.ctx.imports["fmt"] = true
+	split := n.Sep != "" || n.Width != nil
+	if split && asexpr {
+		g.ctx.cw.Print("fmt.Scan(&_t)")
+		return nil
+	}
+	if split {
+		g.ctx.cw.Println("{")
+		g.ctx.cw.Indent(1)
+		g.ctx.cw.Println("var _t string")
+		g.ctx.cw.Println("fmt.Scan(&_t)")
+		err := g.scanFields(n)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Indent(-1)
+		g.ctx.cw.Println("}")
+		return nil
+	}
+	if !asexpr && (hasChar(g.ctx, n) || hasSlice(n)) {
+		return g.scanEach(n)
+	}
+
 	g.ctx.cw.Print("fmt.Scan(")
 	for i, f := range n.RefList {
 		if i > 0 {
 			g.ctx.cw.Print(", ")
 		}
-		g.ctx.cw.Printf("&%s", f.Ident)
-		for _, i := range f.Indices {
-			g.ctx.cw.Print("[")
-			err := genExpr(g.ctx, &i)
+		g.ctx.cw.Print(addr(g.ctx, &f))
+		err := genRef(g.ctx, &f)
+		if err != nil {
+			return err
+		}
+	}
+	g.ctx.cw.Print(")")
+	if !asexpr {
+		g.ctx.cw.Println()
+	}
+	return nil
+}
+
+// scanEach scans the references of n one at a time, looping over slices of
+// arrays and reading chars and rows of char arrays from a string.
+func (g *Generator) scanEach(n *ast.ScanStmt) error {
+	for _, f := range n.RefList {
+		err := g.openSliceLoop(&f)
+		if err != nil {
+			return err
+		}
+		k := f.Ident + strings.Repeat("[]", len(f.Indices))
+		if g.ctx.types[k] != "byte" && !isCharRow(g.ctx, &f) {
+			g.ctx.cw.Print("fmt.Scan(" + addr(g.ctx, &f))
+			err = genRef(g.ctx, &f)
 			if err != nil {
 				return err
 			}
-			g.ctx.cw.Print("]")
+			g.ctx.cw.Println(")")
+		} else {
+			g.ctx.cw.Println("{")
+			g.ctx.cw.Indent(1)
+			g.ctx.cw.Println("var _t string")
+			g.ctx.cw.Println("fmt.Scan(&_t)")
+			err = g.scanField(&f, "_t")
+			if err != nil {
+				return err
+			}
+			g.ctx.cw.Indent(-1)
+			g.ctx.cw.Println("}")
 		}
+		g.closeSliceLoop(&f)
 	}
-	g.ctx.cw.Print(")")
-	if !asexpr {
+	return nil
+}
+
+// scanField stores the field src, a string expression, into f.
+func (g *Generator) scanField(f *ast.Reference, src string) error {
+	k := f.Ident + strings.Repeat("[]", len(f.Indices))
+	switch {
+	case isCharRow(g.ctx, f):
+		g.ctx.cw.Print("copy(")
+		err := genRef(g.ctx, f)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Printf(", %s)", src)
+	case g.ctx.types[k] == "byte":
+		err := genRef(g.ctx, f)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Printf(" = %s[0]", src)
+		if len(f.Indices) == 0 {
+			// Assigning to a variable does not use it, as Go requires.
+			g.ctx.cw.Println()
+			g.ctx.cw.Printf("_ = %s", f.Ident)
+		}
+	default:
+		g.ctx.cw.Printf("fmt.Sscan(%s, %s", src, addr(g.ctx, f))
+		err := genRef(g.ctx, f)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Print(")")
+	}
+	g.ctx.cw.Println()
+	return nil
+}
+
+func hasSlice(n *ast.ScanStmt) bool {
+	for _, f := range n.RefList {
+		if f.High != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// addr returns the operator that takes the address of f to scan into, or
+// nothing if f is a *big.Int, which is scanned into as is.
+func addr(ctx *Context, f *ast.Reference) string {
+	if ctx.types[f.Ident+strings.Repeat("[]", len(f.Indices))] == bigType {
+		return ""
+	}
+	return "&"
+}
+
+// genRef emits the variable or array element f refers to. For a slice of an
+// array, it emits the element at _k, the index of the loop openSliceLoop
+// emits.
+func genRef(ctx *Context, f *ast.Reference) error {
+	ctx.cw.Print(f.Ident)
+	indices := f.Indices
+	if f.High != nil {
+		indices = indices[:len(indices)-1]
+	}
+	for i := range indices {
+		ctx.cw.Print("[")
+		err := genExpr(ctx, &indices[i])
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print("]")
+	}
+	if f.High != nil {
+		ctx.cw.Print("[_k]")
+	}
+	return nil
+}
+
+// openSliceLoop opens a loop over the elements of the slice of an array f
+// refers to, if it refers to one.
+func (g *Generator) openSliceLoop(f *ast.Reference) error {
+	if f.High == nil {
+		return nil
+	}
+	g.ctx.cw.Print("for _k := ")
+	err := genExpr(g.ctx, &f.Indices[len(f.Indices)-1])
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Print("; _k < ")
+	err = genExpr(g.ctx, f.High)
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Println("; _k++ {")
+	g.ctx.cw.Indent(1)
+	return nil
+}
+
+func (g *Generator) closeSliceLoop(f *ast.Reference) {
+	if f.High == nil {
+		return
+	}
+	g.ctx.cw.Indent(-1)
+	g.ctx.cw.Println("}")
+}
+
+// isCharRow reports whether f refers to a row of a char array, which is
+// scanned from a single token.
+func isCharRow(ctx *Context, f *ast.Reference) bool {
+	k := f.Ident + strings.Repeat("[]", len(f.Indices))
+	return ctx.types[k] == "array" && ctx.types[k+"[]"] == "byte"
+}
+
+// hasChar reports whether n scans a char or a row of a char array.
+func hasChar(ctx *Context, n *ast.ScanStmt) bool {
+	for _, f := range n.RefList {
+		if ctx.types[f.Ident+strings.Repeat("[]", len(f.Indices))] == "byte" || isCharRow(ctx, &f) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanFields reads the fields of n from the token in _t, split by the
+// separator or the width of n. Unless the fields can be indexed, as separated
+// fields of no slices can, each field is dropped once it is read.
+func (g *Generator) scanFields(n *ast.ScanStmt) error {
+	if n.Sep != "" && !hasSlice(n) {
+		g.ctx.imports["strings"] = true
+		g.ctx.cw.Printf("_f := strings.Split(_t, %q)", n.Sep)
 		g.ctx.cw.Println()
+		for k, f := range n.RefList {
+			err := g.scanField(&f, fmt.Sprintf("_f[%d]", k))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	src, rest := "_f[0]", "_f = _f[1:]"
+	if n.Sep != "" {
+		g.ctx.imports["strings"] = true
+		g.ctx.cw.Printf("_f := strings.Split(_t, %q)", n.Sep)
+		g.ctx.cw.Println()
+	} else {
+		cw := g.ctx.cw
+		g.ctx.cw = code.NewWriter("\t")
+		err := genExpr(g.ctx, n.Width)
+		w := string(g.ctx.cw.Bytes())
+		g.ctx.cw = cw
+		if err != nil {
+			return err
+		}
+		src, rest = "_t[:"+w+"]", "_t = _t["+w+":]"
+	}
+	for _, f := range n.RefList {
+		err := g.openSliceLoop(&f)
+		if err != nil {
+			return err
+		}
+		err = g.scanField(&f, src)
+		if err != nil {
+			return err
+		}
+		g.ctx.cw.Println(rest)
+		g.closeSliceLoop(&f)
 	}
 	return nil
 }
@@ -244,6 +606,10 @@ func (g *Generator) forScanStmt(n *ast.ForStmt) error {
 	g.ctx.imports["io"] = true
 	g.ctx.cw.Println("for {")
 	g.ctx.cw.Indent(1)
+	split := n.Scan.Sep != "" || n.Scan.Width != nil
+	if split {
+		g.ctx.cw.Println("var _t string")
+	}
 	g.ctx.cw.Print("if _, err := ")
 	g.scanStmt(n.Scan, true)
 	g.ctx.cw.Println("; err == io.EOF {")
@@ -251,6 +617,12 @@ func (g *Generator) forScanStmt(n *ast.ForStmt) error {
 	g.ctx.cw.Println("break")
 	g.ctx.cw.Indent(-1)
 	g.ctx.cw.Println("}")
+	if split {
+		err := g.scanFields(n.Scan)
+		if err != nil {
+			return err
+		}
+	}
 	ast.Walk(g, &n.Block)
 	g.ctx.cw.Indent(-1)
 	g.ctx.cw.Print("}")
@@ -310,17 +682,67 @@ func genOpLogicalOr(ctx *Context, n *ast.OpLogicalOr) error {
 }
 
 func genLogicalAnd(ctx *Context, n *ast.LogicalAnd) error {
-	err := genRelative(ctx, n.Left)
+	if n.Interval != nil {
+		return genInterval(ctx, n.Left, n.Interval)
+	}
+	if len(n.Right) == 0 {
+		return genRelative(ctx, n.Left)
+	}
+	l := n.Left
+	for i, c := range n.Right {
+		if i > 0 {
+			// Comparisons chain: a < b < c is emitted as a < b && b < c.
+			ctx.cw.Print("&&")
+		}
+		err := genComparison(ctx, l, string(c.Operator), c.Relative)
+		if err != nil {
+			return err
+		}
+		l = c.Relative
+	}
+	return nil
+}
+
+func genInterval(ctx *Context, v *ast.Relative, n *ast.Interval) error {
+	op := ">"
+	if n.LowClosed {
+		op = ">="
+	}
+	err := genComparison(ctx, v, op, &n.Low)
 	if err != nil {
 		return err
 	}
-	for _, c := range n.Right {
-		err := genOpRelative(ctx, c)
+	ctx.cw.Print("&&")
+	op = "<"
+	if n.HighClosed {
+		op = "<="
+	}
+	return genComparison(ctx, v, op, &n.High)
+}
+
+// genComparison emits l op r, converting the operands to their common type.
+// Operands of type bigint are compared with Cmp.
+func genComparison(ctx *Context, l ast.Node, op string, r ast.Node) error {
+	t := code.Promote(exprType(ctx, l), exprType(ctx, r))
+	if t == "bigint" {
+		err := genBig(ctx, l)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(".Cmp(")
+		err = genBig(ctx, r)
 		if err != nil {
 			return err
 		}
+		ctx.cw.Printf(")%s0", op)
+		return nil
 	}
-	return nil
+	err := genConverted(ctx, t, l)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(op)
+	return genConverted(ctx, t, r)
 }
 
 func genOpLogicalAnd(ctx *Context, n *ast.OpLogicalAnd) error {
@@ -329,31 +751,62 @@ func genOpLogicalAnd(ctx *Context, n *ast.OpLogicalAnd) error {
 }
 
 func genRelative(ctx *Context, n *ast.Relative) error {
-	err := genAddition(ctx, n.Left)
-	if err != nil {
-		return err
-	}
+	operands := []ast.Node{n.Left}
 	for _, c := range n.Right {
-		err := genOpAddition(ctx, c)
-		if err != nil {
-			return err
-		}
+		operands = append(operands, c.Addition)
 	}
-	return nil
+	return genOperation(ctx, operands, func(i int) error {
+		ctx.cw.Print(ASTOperator[n.Right[i].Operator])
+		return nil
+	})
 }
 
-func genOpRelative(ctx *Context, n *ast.OpRelative) error {
-	ctx.cw.Print(string(n.Operator))
-	return genRelative(ctx, n.Relative)
+func genAddition(ctx *Context, n *ast.Addition) error {
+	operands := []ast.Node{n.Left}
+	for _, c := range n.Right {
+		operands = append(operands, c.Factor)
+	}
+	return genOperation(ctx, operands, func(i int) error {
+		ctx.cw.Print(ASTOperator[n.Right[i].Operator])
+		return nil
+	})
 }
 
-func genAddition(ctx *Context, n *ast.Addition) error {
-	err := genMultiplication(ctx, n.Left)
+// genOperation emits the left-associative operation on operands, with genOp
+// emitting the operator before operands[i+1]. Wherever two operands of
+// different types meet, the narrower one is converted as package eval
+// prescribes, since Go does not convert typed operands implicitly.
+func genOperation(ctx *Context, operands []ast.Node, genOp func(i int) error) error {
+	types := []string{exprType(ctx, operands[0])}
+	for _, o := range operands[1:] {
+		types = append(types, code.Promote(types[len(types)-1], exprType(ctx, o)))
+	}
+	if len(operands) > 1 && types[len(types)-1] == "bigint" {
+		return errBigint
+	}
+	for i := len(operands) - 1; i > 0; i-- {
+		if needsConversion(types[i-1], types[i]) {
+			ctx.cw.Printf("%s(", ASTType[types[i]])
+		}
+	}
+	var err error
+	if code.IsUntyped(types[0]) {
+		err = genConverted(ctx, types[len(types)-1], operands[0])
+	} else {
+		err = genNode(ctx, operands[0])
+	}
 	if err != nil {
 		return err
 	}
-	for _, c := range n.Right {
-		err := genOpMultiplication(ctx, c)
+	for i, o := range operands[1:] {
+		if needsConversion(types[i], types[i+1]) {
+			ctx.cw.Print(")")
+		}
+		err := genOp(i)
+		if err != nil {
+			return err
+		}
+		err = genConverted(ctx, types[i+1], o)
 		if err != nil {
 			return err
 		}
@@ -361,18 +814,90 @@ func genAddition(ctx *Context, n *ast.Addition) error {
 	return nil
 }
 
-func genOpAddition(ctx *Context, n *ast.OpAddition) error {
-	ctx.cw.Print(string(n.Operator))
-	return genAddition(ctx, n.Addition)
+// genConverted emits n, converted to type t if needed. An untyped n takes type
+// t, as the constants in it do in Go.
+func genConverted(ctx *Context, t string, n ast.Node) error {
+	from := exprType(ctx, n)
+	if code.IsUntyped(from) {
+		if t != "" && !code.IsUntyped(t) {
+			return genTyped(ctx, t, n)
+		}
+		return genNode(ctx, n)
+	}
+	if !needsConversion(from, t) {
+		return genNode(ctx, n)
+	}
+	ctx.cw.Printf("%s(", ASTType[t])
+	err := genNode(ctx, n)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
 }
 
-func genMultiplication(ctx *Context, n *ast.Multiplication) error {
-	return genUnary(ctx, n.Unary)
+// needsConversion reports whether a value of type from must be converted
+// explicitly to be used as type to. Untyped constants need no conversion.
+func needsConversion(from, to string) bool {
+	return from != "" && to != "" && from != to && !code.IsUntyped(from)
+}
+
+// genTyped emits n with its untyped operands taking type t, or their default
+// type if t is empty.
+func genTyped(ctx *Context, t string, n ast.Node) error {
+	defer func(t string) { ctx.untypedAs = t }(ctx.untypedAs)
+	ctx.untypedAs = t
+	return genNode(ctx, n)
 }
 
-func genOpMultiplication(ctx *Context, n *ast.OpMultiplication) error {
-	ctx.cw.Print(string(n.Operator))
-	return genMultiplication(ctx, n.Factor)
+func genNode(ctx *Context, n ast.Node) error {
+	switch n := n.(type) {
+	case *ast.Expr:
+		return genExpr(ctx, n)
+	case *ast.Relative:
+		return genRelative(ctx, n)
+	case *ast.Addition:
+		return genAddition(ctx, n)
+	case *ast.Multiplication:
+		return genMultiplication(ctx, n)
+	}
+	panic(fmt.Errorf("unreachable, with %T", n))
+}
+
+func genMultiplication(ctx *Context, n *ast.Multiplication) error {
+	if n.Exponent == nil {
+		return genUnary(ctx, n.Unary)
+	}
+	if v, ok := code.ConstInt(n, ctx.constValues); ok {
+		if v.Sign() < 0 {
+			ctx.cw.Printf("(%s)", v)
+		} else {
+			ctx.cw.Print(v.String())
+		}
+		return nil
+	}
+	ctx.imports["math"] = true
+	t := exprType(ctx, n)
+	switch {
+	case code.IsUntyped(t) && ctx.untypedAs != "":
+		t = ctx.untypedAs
+	case t == "", t == eval.UntypedInt:
+		t = "int"
+	case t == eval.UntypedFloat:
+		t = "float64"
+	}
+	ctx.cw.Printf("%s(math.Pow(float64(", ASTType[t])
+	err := genUnary(ctx, n.Unary)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print("), float64(")
+	err = genPrimary(ctx, n.Exponent)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")))")
+	return nil
 }
 
 func genUnary(ctx *Context, n *ast.Unary) error {
@@ -383,53 +908,191 @@ func genUnary(ctx *Context, n *ast.Unary) error {
 	case n.Negated != nil:
 		ctx.cw.Print("-")
 		return genPrimary(ctx, n.Negated)
+	case n.Not != nil:
+		ctx.cw.Print("!")
+		return genPrimary(ctx, n.Not)
 	}
 	panic("unreachable")
 }
 
 func genPrimary(ctx *Context, n *ast.Primary) error {
 	switch {
+	case n.Conversion != nil:
+		ctx.cw.Printf("%s(", ASTType[n.Conversion.Type])
+		err := genTyped(ctx, "", &n.Conversion.Expr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
+
+	case n.Quantifier != nil:
+		return genQuantifier(ctx, n.Quantifier)
+
 	case n.CallExpr != nil:
-		// args := []interface{}{}
-		// for _, a := range n.Call.Arguments {
-		// 	v, err := genExpr(ctx, &a)
-		// 	if err != nil {
-		// 		return err
-		// 	}
-		// 	args = append(args, v)
-		// }
-		// return Functions[n.Call.Name](args...)
+		return genCall(ctx, n.CallExpr)
 
 	case n.Variable != nil:
-		// indices := []int{}
-		// for _, i := range n.Variable.Indices {
-		// 	v, err := genExpr(ctx, &i)
-		// 	if err != nil {
-		// 		return err
-		// 	}
-		// 	vi, ok := v.(int)
-		// 	if !ok {
-		// 		return nil, ErrNonIntegerIndex{}
-		// 	}
-		// 	indices = append(indices, vi)
-		// }
-		// return ctx.GetValue(n.Variable.Identifier, indices).Data, nil
-		ctx.cw.Print(n.Variable.Ident)
-		return nil
+		return genVariable(ctx, n.Variable)
 
 	case n.BasicLit != nil:
 		return genBasicLit(ctx, n.BasicLit)
 
 	case n.SubExpr != nil:
-		return genExpr(ctx, n.SubExpr)
+		ctx.cw.Print("(")
+		err := genExpr(ctx, n.SubExpr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
 	}
 	panic("unreachable")
 }
 
+// genCall emits the call to a built-in function n as ASTFunction spells it.
+func genCall(ctx *Context, n *ast.CallExpr) error {
+	f, ok := ASTFunction[n.Ident]
+	if !ok {
+		return fmt.Errorf("go1: built-in function %s cannot be generated", n.Ident)
+	}
+	if strings.Contains(f, "strings.") {
+		ctx.imports["strings"] = true
+	}
+	if _, ok := helperDefs[n.Ident]; ok {
+		ctx.helpers[n.Ident] = true
+	}
+	cw := ctx.cw
+	defer func() { ctx.cw = cw }()
+	args := make([]interface{}, len(n.Args))
+	for i := range n.Args {
+		ctx.cw = code.NewWriter("\t")
+		err := genTyped(ctx, "", &n.Args[i])
+		if err != nil {
+			return err
+		}
+		args[i] = string(ctx.cw.Bytes())
+	}
+	cw.Printf(f, args...)
+	return nil
+}
+
+// genQuantifier emits n as a function literal, called in place, that loops
+// over the range of n until the outcome is known.
+func genQuantifier(ctx *Context, n *ast.Quantifier) error {
+	ctx.cw.Println("func() bool {")
+	ctx.cw.Indent(1)
+	ctx.cw.Printf("for %s := ", n.Index)
+	err := genExpr(ctx, &n.Low)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf("; %s < ", n.Index)
+	err = genExpr(ctx, &n.High)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf("; %s++ {", n.Index)
+	ctx.cw.Println()
+	ctx.cw.Indent(1)
+	all := n.Kind == "all"
+	if all {
+		ctx.cw.Print("if !(")
+	} else {
+		ctx.cw.Print("if ")
+	}
+	err = genExpr(ctx, &n.Expr)
+	if err != nil {
+		return err
+	}
+	if all {
+		ctx.cw.Print(")")
+	}
+	ctx.cw.Println(" {")
+	ctx.cw.Indent(1)
+	ctx.cw.Printf("return %t", !all)
+	ctx.cw.Println()
+	ctx.cw.Indent(-1)
+	ctx.cw.Println("}")
+	ctx.cw.Indent(-1)
+	ctx.cw.Println("}")
+	ctx.cw.Printf("return %t", all)
+	ctx.cw.Println()
+	ctx.cw.Indent(-1)
+	ctx.cw.Print("}()")
+	return nil
+}
+
+// genBig emits n as a *big.Int. Besides bigint variables, n may be an integer
+// constant or of a narrower integer type.
+func genBig(ctx *Context, n ast.Node) error {
+	if v := code.SoleVariable(n); v != nil && variableType(ctx, v) == bigType {
+		return genElement(ctx, v)
+	}
+	if v, ok := code.ConstInt(n, ctx.constValues); ok {
+		if v.IsInt64() {
+			ctx.cw.Printf("big.NewInt(%s)", v)
+			return nil
+		}
+		name := fmt.Sprintf("_big%d", ctx.bigConsts)
+		ctx.bigConsts++
+		ctx.consts.Printf("var %s, _ = new(big.Int).SetString(%q, 10)", name, v.String())
+		ctx.consts.Println()
+		ctx.cw.Print(name)
+		return nil
+	}
+	var pre, post string
+	switch exprType(ctx, n) {
+	case "int", "uint32", "byte":
+		pre, post = "big.NewInt(int64(", "))"
+	case "int64":
+		pre, post = "big.NewInt(", ")"
+	case "uint64":
+		pre, post = "new(big.Int).SetUint64(", ")"
+	default:
+		return errBigint
+	}
+	ctx.cw.Print(pre)
+	err := genNode(ctx, n)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(post)
+	return nil
+}
+
+// genVariable emits n with its indices, the last of which may be a slice.
+func genVariable(ctx *Context, n *ast.Variable) error {
+	if variableType(ctx, n) == bigType {
+		return errBigint
+	}
+	return genElement(ctx, n)
+}
+
+func genElement(ctx *Context, n *ast.Variable) error {
+	ctx.cw.Print(n.Ident)
+	for i := range n.Indices {
+		ctx.cw.Print("[")
+		err := genTyped(ctx, "", &n.Indices[i])
+		if err != nil {
+			return err
+		}
+		if i == len(n.Indices)-1 && n.High != nil {
+			ctx.cw.Print(":")
+			err = genTyped(ctx, "", n.High)
+			if err != nil {
+				return err
+			}
+		}
+		ctx.cw.Print("]")
+	}
+	return nil
+}
+
 func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	switch {
 	case n.FloatLit != nil:
-		ctx.cw.Printf("%f", *n.FloatLit)
+		ctx.cw.Print(code.FormatFloat(*n.FloatLit))
 		return nil
 
 	case n.IntLit != nil:
@@ -439,6 +1102,14 @@ func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	case n.StringLit != nil:
 		ctx.cw.Printf("%q", *n.StringLit)
 		return nil
+
+	case n.CharLit != nil:
+		ctx.cw.Print(strconv.QuoteRuneToASCII(rune(*n.CharLit)))
+		return nil
+
+	case n.BoolLit != nil:
+		ctx.cw.Printf("%t", *n.BoolLit)
+		return nil
 	}
 
 	panic("unreachable")
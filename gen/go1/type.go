@@ -1,10 +1,114 @@
 package go1
 
+import (
+	"errors"
+	"strings"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"git.furqansoftware.net/toph/scanlib/gen/code"
+)
+
 var ASTType = map[string]string{
 	"bool":    "bool",
+	"char":    "byte",
 	"int":     "int",
 	"int64":   "int64",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"bigint":  bigType,
 	"float32": "float32",
 	"float64": "float64",
+	"decimal": "float64",
 	"string":  "string",
 }
+
+// ASTFunction maps the built-in functions that can be generated to the format
+// of their calls, taking the arguments in order.
+var ASTFunction = map[string]string{
+	"len":          "len(%s)",
+	"isLower":      `(strings.Trim(%s, "abcdefghijklmnopqrstuvwxyz") == "")`,
+	"isUpper":      `(strings.Trim(%s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "")`,
+	"isDigits":     `(strings.Trim(%s, "0123456789") == "")`,
+	"onlyChars":    `(strings.Trim(%s, %s) == "")`,
+	"countChar":    "strings.Count(%s, string(%s))",
+	"contains":     "strings.Contains(%s, %s)",
+	"hasPrefix":    "strings.HasPrefix(%s, %s)",
+	"hasSuffix":    "strings.HasSuffix(%s, %s)",
+	"upper":        "strings.ToUpper(%s)",
+	"lower":        "strings.ToLower(%s)",
+	"isPalindrome": "isPalindrome(%s)",
+}
+
+// helperDefs holds the definitions of the functions that generated code calls.
+var helperDefs = map[string]string{
+	"isPalindrome": "func isPalindrome(s string) bool {\n\tfor i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {\n\t\tif s[i] != s[j] {\n\t\t\treturn false\n\t\t}\n\t}\n\treturn true\n}\n",
+}
+
+// bigType is the type of bigint values. As *big.Int has no operators, bigint
+// values can only be compared, with Cmp.
+const bigType = "*big.Int"
+
+// errBigint is returned for a bigint operand of anything but a comparison.
+var errBigint = errors.New("go1: bigint values can only be compared")
+
+var ASTOperator = map[ast.Operator]string{
+	"+":   "+",
+	"-":   "-",
+	"|":   "|",
+	"xor": "^",
+	"*":   "*",
+	"/":   "/",
+	"%":   "%",
+	"<<":  "<<",
+	">>":  ">>",
+	"&":   "&",
+}
+
+// isConstExpr reports whether n is a constant expression in Go.
+func isConstExpr(ctx *Context, n ast.Node) bool {
+	c := true
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Multiplication:
+			if n.Exponent != nil {
+				_, ok := code.ConstInt(n, ctx.constValues)
+				c = c && ok
+			}
+		case *ast.CallExpr:
+			c = false
+		case *ast.Variable:
+			c = c && ctx.constants[n.Ident]
+		}
+		return c
+	})
+	return c
+}
+
+// exprType infers the scanspec type of n following the promotion rules of
+// package eval. It returns "" if the type is unknown.
+func exprType(ctx *Context, n ast.Node) string {
+	return code.ExprType(n, func(v *ast.Variable) string {
+		if t := variableType(ctx, v); t != bigType {
+			return t
+		}
+		return "bigint"
+	})
+}
+
+// variableType returns the scanspec type of n. Indexing into a string gives a
+// char, and slicing keeps the type of what is sliced.
+func variableType(ctx *Context, n *ast.Variable) string {
+	k := len(n.Indices)
+	if n.High != nil {
+		k--
+	}
+	t := ctx.types[n.Ident]
+	for i := 1; i <= k; i++ {
+		if t == "string" {
+			t = "char"
+			continue
+		}
+		t = ctx.types[n.Ident+strings.Repeat("[]", i)]
+	}
+	return t
+}
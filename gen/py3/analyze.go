@@ -9,13 +9,15 @@ import (
 type analyzer struct {
 	ozs map[ast.Node]Optimization
 
-	blockEOLs map[*ast.Block]bool
+	blockEOLs  map[*ast.Block]bool
+	loopBlocks map[*ast.Block]bool
 }
 
 func analyze(n *ast.Source) *analyzer {
 	a := analyzer{
-		ozs:       map[ast.Node]Optimization{},
-		blockEOLs: map[*ast.Block]bool{},
+		ozs:        map[ast.Node]Optimization{},
+		blockEOLs:  map[*ast.Block]bool{},
+		loopBlocks: map[*ast.Block]bool{},
 	}
 	findBlockEOLs(&a, n)
 	ast.Walk(&a, n)
@@ -28,7 +30,11 @@ func (a *analyzer) Visit(n ast.Node) ast.Visitor {
 	}
 
 	switch n := n.(type) {
-	case *ast.Source, *ast.Statement, *ast.ForStmt, *ast.IfStmt, *ast.IfBranch:
+	case *ast.Source, *ast.Statement, *ast.IfStmt, *ast.IfBranch, *ast.SubtaskStmt:
+		return a
+
+	case *ast.ForStmt:
+		a.loopBlocks[&n.Block] = true
 		return a
 
 	case *ast.Block:
@@ -49,7 +55,7 @@ func findBlockEOLs(a *analyzer, n *ast.Source) {
 			stack = stack[:len(stack)-1]
 		}
 		switch n := n.(type) {
-		case *ast.Source, *ast.Block, *ast.Statement, *ast.ForStmt, *ast.IfStmt, *ast.IfBranch:
+		case *ast.Source, *ast.Block, *ast.Statement, *ast.ForStmt, *ast.IfStmt, *ast.IfBranch, *ast.SubtaskStmt:
 			stack = append(stack, n)
 			return true
 		case *ast.EOLStmt:
@@ -65,4 +71,31 @@ func findBlockEOLs(a *analyzer, n *ast.Source) {
 	})
 }
 
+// inspect is like ast.Inspect, but passes over statements that generate no code
+// in place.
+func inspect(n ast.Node, f func(ast.Node) bool) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if s, ok := n.(*ast.Statement); ok && isInert(s) {
+			return false
+		}
+		return f(n)
+	})
+}
+
+// isInert reports whether s generates no code in place.
+func isInert(s *ast.Statement) bool {
+	switch {
+	case s.ConstDecl != nil, s.LetDecl != nil, s.CheckStmt != nil:
+		return true
+	case s.SubtaskStmt != nil:
+		for _, s := range s.SubtaskStmt.Block.Statements {
+			if !isInert(s) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 type State int
@@ -21,7 +21,7 @@ func (o arrayLine) Generate(ctx *Context) error {
 		ctx.cw.Printf("%s", x.Ident)
 	}
 	t := ASTType[*o.varDecl.VarSpec.Type.TypeLit.ArrayType.ElementType.TypeName]
-	ctx.cw.Printf(" = map(%s, input().split())", t)
+	ctx.cw.Printf(" = list(map(%s, input().split()))", t)
 	ctx.cw.Println()
 	return nil
 }
@@ -41,7 +41,7 @@ func (a *analyzer) arrayLine(n *ast.Block) {
 		state State
 		depth int
 	)
-	ast.Inspect(n, func(n ast.Node) bool {
+	inspect(n, func(n ast.Node) bool {
 		if n == nil {
 			depth--
 		}
@@ -114,7 +114,7 @@ func (a *analyzer) arrayLine(n *ast.Block) {
 				return false
 
 			case *ast.ScanStmt:
-				if len(n.RefList) == 1 &&
+				if len(n.RefList) == 1 && n.Sep == "" && n.Width == nil && n.RefList[0].High == nil &&
 					n.RefList[0].Ident == oz.varDecl.VarSpec.IdentList[0] &&
 					len(n.RefList[0].Indices) == 1 &&
 					exprEqVar(&n.RefList[0].Indices[0], oz.forStmt.Range.Index) {
@@ -232,7 +232,7 @@ func exprInt64(e *ast.Expr) (int64, bool) {
 		e.Left.Left.Left.Left.Left.Unary.Value != nil &&
 		e.Left.Left.Left.Left.Left.Unary.Value.BasicLit != nil &&
 		e.Left.Left.Left.Left.Left.Unary.Value.BasicLit.IntLit != nil {
-		return *e.Left.Left.Left.Left.Left.Unary.Value.BasicLit.IntLit, true
+		return int64(*e.Left.Left.Left.Left.Left.Unary.Value.BasicLit.IntLit), true
 	}
 	return 0, false
 }
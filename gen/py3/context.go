@@ -7,8 +7,10 @@ import (
 )
 
 type Context struct {
-	types map[string]string
-	cw    *code.Writer
+	types   map[string]string
+	helpers map[string]bool
+	consts  *code.Writer
+	cw      *code.Writer
 
 	linevar bool
 }
@@ -2,7 +2,12 @@
 
 package py3
 
-import "git.furqansoftware.net/toph/scanlib/ast"
+import (
+	"strconv"
+	"strings"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+)
 
 type multiVar struct {
 	varDecl  *ast.VarDecl
@@ -11,26 +16,54 @@ type multiVar struct {
 }
 
 func (o multiVar) Generate(ctx *Context) error {
-	for i, x := range o.scanStmt.RefList {
-		if i > 0 {
-			ctx.cw.Print(", ")
+	names := []string{}
+	types := []string{}
+	for _, x := range o.scanStmt.RefList {
+		names = append(names, x.Ident)
+		types = append(types, scanFunc(ctx, &x))
+	}
+	lhs := strings.Join(names, ", ")
+	t := types[0]
+	for _, u := range types[1:] {
+		if u != t {
+			t = ""
 		}
-		ctx.cw.Printf("%s", x.Ident)
 	}
-	t := ASTType[*o.varDecl.VarSpec.Type.TypeName]
-	if len(o.scanStmt.RefList) == 1 {
-		if t == "string" {
-			ctx.cw.Print(" = input()")
-		} else {
-			ctx.cw.Printf(" = %s(input())", t)
+	switch {
+	case len(names) == 1 && t == "string":
+		ctx.cw.Printf("%s = input()", lhs)
+	case len(names) == 1:
+		ctx.cw.Printf("%s = %s(input())", lhs, t)
+	case t != "":
+		ctx.cw.Printf("%s = map(%s, input().split(%s))", lhs, t, sepArg(o.scanStmt))
+	default:
+		// Variables of different types are converted one by one.
+		ctx.cw.Printf("%s = input().split(%s)", lhs, sepArg(o.scanStmt))
+		ctx.cw.Println()
+		ctx.cw.Printf("%s = ", lhs)
+		for i := range names {
+			if i > 0 {
+				ctx.cw.Print(", ")
+			}
+			if types[i] == "string" {
+				ctx.cw.Print(names[i])
+			} else {
+				ctx.cw.Printf("%s(%s)", types[i], names[i])
+			}
 		}
-	} else {
-		ctx.cw.Printf(" = map(%s, input().split())", t)
 	}
 	ctx.cw.Println()
 	return nil
 }
 
+// sepArg returns the argument to split the input line of n with.
+func sepArg(n *ast.ScanStmt) string {
+	if n.Sep == "" {
+		return ""
+	}
+	return strconv.Quote(n.Sep)
+}
+
 func (a *analyzer) multiVar(n *ast.Block) {
 	const (
 		zero State = iota
@@ -41,7 +74,7 @@ func (a *analyzer) multiVar(n *ast.Block) {
 	oz := multiVar{}
 
 	var state State
-	ast.Inspect(n, func(n ast.Node) bool {
+	inspect(n, func(n ast.Node) bool {
 		if n == nil {
 			return false
 		}
@@ -72,7 +105,7 @@ func (a *analyzer) multiVar(n *ast.Block) {
 				return false
 
 			case *ast.ScanStmt:
-				intersect := true
+				intersect := n.Width == nil
 				idents := map[string]bool{}
 				for _, x := range oz.varDecl.VarSpec.IdentList {
 					idents[x] = true
@@ -3,8 +3,6 @@
 package py3
 
 import (
-	"strings"
-
 	"git.furqansoftware.net/toph/scanlib/ast"
 )
 
@@ -23,7 +21,7 @@ func (o onlyToken) Generate(ctx *Context) error {
 		}
 		ctx.cw.Print("]")
 	}
-	t := ctx.types[o.scanStmt.RefList[0].Ident+strings.Repeat("[]", len(o.scanStmt.RefList[0].Indices))]
+	t := scanFunc(ctx, &o.scanStmt.RefList[0])
 	if t == "string" {
 		ctx.cw.Print(" = input()")
 	} else {
@@ -41,8 +39,11 @@ func (a *analyzer) onlyToken(n *ast.Block) {
 
 	oz := onlyToken{}
 
+	// A line is fresh until a statement that may scan from it, such as a
+	// loop, leaves it unfinished.
 	var state State
-	ast.Inspect(n, func(n ast.Node) bool {
+	fresh := true
+	inspect(n, func(n ast.Node) bool {
 		if n == nil {
 			return false
 		}
@@ -54,10 +55,19 @@ func (a *analyzer) onlyToken(n *ast.Block) {
 				return true
 
 			case *ast.ScanStmt:
-				if len(n.RefList) == 1 {
+				if fresh && len(n.RefList) == 1 && n.RefList[0].High == nil {
 					oz.scanStmt = n
 					state++
 				}
+				fresh = false
+				return false
+
+			case *ast.EOLStmt:
+				fresh = true
+				return false
+
+			case *ast.ForStmt, *ast.IfStmt, *ast.ScanlnStmt:
+				fresh = false
 				return false
 
 			default:
@@ -77,6 +87,7 @@ func (a *analyzer) onlyToken(n *ast.Block) {
 				a.ozs[oz.scanStmt] = oz
 				a.ozs[oz.eolStmt] = Noop{}
 				state = zero
+				fresh = true
 				return false
 
 			default:
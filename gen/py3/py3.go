@@ -5,6 +5,7 @@ package py3
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"git.furqansoftware.net/toph/scanlib/ast"
@@ -14,12 +15,15 @@ import (
 type Generator struct {
 	ctx      *Context
 	analyzer *analyzer
+	err      error
 }
 
 func Generate(n *ast.Source) ([]byte, error) {
 	ctx := Context{
-		types: map[string]string{},
-		cw:    code.NewWriter("\t"),
+		types:   map[string]string{},
+		helpers: map[string]bool{},
+		consts:  code.NewWriter("\t"),
+		cw:      code.NewWriter("\t"),
 	}
 
 	g := Generator{
@@ -28,8 +32,21 @@ func Generate(n *ast.Source) ([]byte, error) {
 	}
 
 	ast.Walk(&g, n)
+	if g.err != nil {
+		return nil, g.err
+	}
 
 	r := bytes.Buffer{}
+	helpers := []string{}
+	for k := range ctx.helpers {
+		helpers = append(helpers, k)
+	}
+	sort.Strings(helpers)
+	for _, h := range helpers {
+		r.WriteString(helperDefs[h])
+		r.WriteString("\n")
+	}
+	r.Write(ctx.consts.Bytes())
 	if ctx.linevar {
 		r.WriteString("_ = None\n")
 	}
@@ -39,45 +56,71 @@ func Generate(n *ast.Source) ([]byte, error) {
 }
 
 func (g *Generator) Visit(n ast.Node) (w ast.Visitor) {
-	if n == nil {
+	if n == nil || g.err != nil {
 		return nil
 	}
 
 	switch n := n.(type) {
-	case *ast.Source, *ast.Block, *ast.Statement:
+	case *ast.Source, *ast.Block, *ast.Statement, *ast.SubtaskStmt:
 		return g
 
-	case *ast.CheckStmt, *ast.EOFStmt:
+	case *ast.LetDecl, *ast.CheckStmt, *ast.EOFStmt:
+		return nil
+
+	case *ast.ConstDecl:
+		g.fail(g.constDecl(n))
 		return nil
 
 	case *ast.VarDecl:
-		g.varDecl(n)
+		g.fail(g.varDecl(n))
 		return nil
 
 	case *ast.ScanStmt:
-		g.scanStmt(n)
+		g.fail(g.scanStmt(n))
 		return nil
 
 	case *ast.ScanlnStmt:
-		g.scanlnStmt(n)
+		g.fail(g.scanlnStmt(n))
 		return nil
 
 	case *ast.IfStmt:
-		g.ifStmt(n)
+		g.fail(g.ifStmt(n))
 		return nil
 
 	case *ast.ForStmt:
-		g.forStmt(n)
+		g.fail(g.forStmt(n))
 		return nil
 
 	case *ast.EOLStmt:
-		g.eolStmt(n)
+		g.fail(g.eolStmt(n))
 		return nil
 	}
 
 	panic(fmt.Errorf("unreachable, with %T", n))
 }
 
+// fail records err, unless an error is already recorded. Generate returns the
+// first error recorded.
+func (g *Generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+func (g *Generator) constDecl(n *ast.ConstDecl) error {
+	cw := g.ctx.cw
+	defer func() { g.ctx.cw = cw }()
+	g.ctx.cw = g.ctx.consts
+	g.ctx.cw.Printf("%s = ", n.ConstSpec.Ident)
+	err := genExpr(g.ctx, &n.ConstSpec.Value)
+	if err != nil {
+		return err
+	}
+	g.ctx.cw.Println()
+	g.ctx.types[n.ConstSpec.Ident] = exprType(g.ctx, &n.ConstSpec.Value)
+	return nil
+}
+
 func (g *Generator) varDecl(n *ast.VarDecl) error {
 	switch {
 	case n.VarSpec.Type.TypeName != nil:
@@ -87,20 +130,33 @@ func (g *Generator) varDecl(n *ast.VarDecl) error {
 		}
 
 	case n.VarSpec.Type.TypeLit != nil:
-		t := ASTType[*n.VarSpec.Type.TypeLit.ArrayType.ElementType.TypeName]
+		dims, et := n.VarSpec.Type.TypeLit.ArrayType.Dims()
+		t := ASTType[et]
 
 		for _, x := range n.VarSpec.IdentList {
-			g.ctx.types[x] = "array"
-			g.ctx.types[x+"[]"] = t
+			for k := range dims {
+				g.ctx.types[x+strings.Repeat("[]", k)] = "array"
+			}
+			g.ctx.types[x+strings.Repeat("[]", len(dims))] = t
 
 			oz, ok := g.analyzer.ozs[n]
 			if ok {
 				return oz.Generate(g.ctx)
 			} else {
-				g.ctx.cw.Printf("%s = [%s] * ", x, ASTZero[t])
-				err := genExpr(g.ctx, &n.VarSpec.Type.TypeLit.ArrayType.ArrayLength)
-				if err != nil {
-					return err
+				// A list of lists for each dimension but the last, as in
+				// [[0] * M for _ in range(N)].
+				g.ctx.cw.Printf("%s = %s[%s] * ", x, strings.Repeat("[", len(dims)-1), ASTZero[et])
+				for k := len(dims) - 1; k >= 0; k-- {
+					if k < len(dims)-1 {
+						g.ctx.cw.Print(" for _ in range(")
+					}
+					err := genOperand(g.ctx, dims[k])
+					if err != nil {
+						return err
+					}
+					if k < len(dims)-1 {
+						g.ctx.cw.Print(")]")
+					}
 				}
 				g.ctx.cw.Println()
 			}
@@ -117,18 +173,73 @@ func (g *Generator) scanStmt(n *ast.ScanStmt) error {
 
 	g.ctx.linevar = true
 	g.ctx.cw.Println("if _ == None: _ = input().split()")
+	err := genSplit(g.ctx, n)
+	if err != nil {
+		return err
+	}
+	return genPops(g.ctx, n)
+}
+
+// genSplit replaces the next token with its fields, if n has a separator or a
+// width.
+func genSplit(ctx *Context, n *ast.ScanStmt) error {
+	switch {
+	case n.Sep != "":
+		ctx.cw.Printf("_[0:1] = _[0].split(%q)", n.Sep)
+		ctx.cw.Println()
+	case n.Width != nil:
+		ctx.cw.Print("_[0:1] = [_[0][_i:_i + ")
+		err := genExpr(ctx, n.Width)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print("] for _i in range(0, len(_[0]), ")
+		err = genExpr(ctx, n.Width)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Println(")]")
+	}
+	return nil
+}
+
+// genPops assigns the next tokens to the references of n, looping over the
+// elements of slices of arrays.
+func genPops(ctx *Context, n *ast.ScanStmt) error {
 	for _, f := range n.RefList {
-		g.ctx.cw.Printf("%s", f.Ident)
-		for _, i := range f.Indices {
-			g.ctx.cw.Print("[")
-			err := genExpr(g.ctx, &i)
+		indices := f.Indices
+		if f.High != nil {
+			indices = indices[:len(indices)-1]
+			ctx.cw.Print("for _k in range(")
+			err := genExpr(ctx, &f.Indices[len(indices)])
 			if err != nil {
 				return err
 			}
-			g.ctx.cw.Print("]")
+			ctx.cw.Print(", ")
+			err = genExpr(ctx, f.High)
+			if err != nil {
+				return err
+			}
+			ctx.cw.Println("):")
+			ctx.cw.Indent(1)
+		}
+		ctx.cw.Printf("%s", f.Ident)
+		for _, i := range indices {
+			ctx.cw.Print("[")
+			err := genExpr(ctx, &i)
+			if err != nil {
+				return err
+			}
+			ctx.cw.Print("]")
+		}
+		if f.High != nil {
+			ctx.cw.Print("[_k]")
+		}
+		ctx.cw.Printf(" = %s(_.pop(0))", scanFunc(ctx, &f))
+		ctx.cw.Println()
+		if f.High != nil {
+			ctx.cw.Indent(-1)
 		}
-		g.ctx.cw.Printf(" = %s(_.pop(0))", g.ctx.types[f.Ident+strings.Repeat("[]", len(f.Indices))])
-		g.ctx.cw.Println()
 	}
 	return nil
 }
@@ -278,6 +389,33 @@ func genExpr(ctx *Context, n *ast.Expr) error {
 	return nil
 }
 
+// isOperand reports whether n is generated as a single operand, which needs no
+// parentheses when substituted into another expression.
+func isOperand(n *ast.Expr) bool {
+	if len(n.Right) > 0 || len(n.Left.Right) > 0 {
+		return false
+	}
+	l := n.Left.Left
+	if len(l.Right) > 0 || l.Interval != nil || len(l.Left.Right) > 0 || len(l.Left.Left.Right) > 0 {
+		return false
+	}
+	return l.Left.Left.Left.Unary.Value != nil
+}
+
+// genOperand emits n, parenthesised unless it is a single operand.
+func genOperand(ctx *Context, n *ast.Expr) error {
+	if isOperand(n) {
+		return genExpr(ctx, n)
+	}
+	ctx.cw.Print("(")
+	err := genExpr(ctx, n)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
+}
+
 func genLogicalOr(ctx *Context, n *ast.LogicalOr) error {
 	err := genLogicalAnd(ctx, n.Left)
 	if err != nil {
@@ -293,11 +431,14 @@ func genLogicalOr(ctx *Context, n *ast.LogicalOr) error {
 }
 
 func genOpLogicalOr(ctx *Context, n *ast.OpLogicalOr) error {
-	ctx.cw.Print("||")
+	ctx.cw.Print(" or ")
 	return genLogicalOr(ctx, n.LogicalOr)
 }
 
 func genLogicalAnd(ctx *Context, n *ast.LogicalAnd) error {
+	if n.Interval != nil {
+		return genInterval(ctx, n.Left, n.Interval)
+	}
 	err := genRelative(ctx, n.Left)
 	if err != nil {
 		return err
@@ -311,12 +452,39 @@ func genLogicalAnd(ctx *Context, n *ast.LogicalAnd) error {
 	return nil
 }
 
+func genInterval(ctx *Context, v *ast.Relative, n *ast.Interval) error {
+	err := genExpr(ctx, &n.Low)
+	if err != nil {
+		return err
+	}
+	if n.LowClosed {
+		ctx.cw.Print("<=")
+	} else {
+		ctx.cw.Print("<")
+	}
+	err = genRelative(ctx, v)
+	if err != nil {
+		return err
+	}
+	if n.HighClosed {
+		ctx.cw.Print("<=")
+	} else {
+		ctx.cw.Print("<")
+	}
+	return genExpr(ctx, &n.High)
+}
+
 func genOpLogicalAnd(ctx *Context, n *ast.OpLogicalAnd) error {
-	ctx.cw.Print("&&")
+	ctx.cw.Print(" and ")
 	return genLogicalAnd(ctx, n.LogicalAnd)
 }
 
 func genRelative(ctx *Context, n *ast.Relative) error {
+	for _, c := range n.Right {
+		if parenOperators[c.Operator] {
+			ctx.cw.Print("(")
+		}
+	}
 	err := genAddition(ctx, n.Left)
 	if err != nil {
 		return err
@@ -335,13 +503,30 @@ func genOpRelative(ctx *Context, n *ast.OpRelative) error {
 	return genRelative(ctx, n.Relative)
 }
 
+// genAddition emits the left-associative operation n. Wherever an operation
+// is on integers, as package eval promotes its operands, operands that are not
+// are converted with int, and division truncates.
 func genAddition(ctx *Context, n *ast.Addition) error {
+	types := []string{exprType(ctx, n.Left)}
+	for _, c := range n.Right {
+		types = append(types, code.Promote(types[len(types)-1], exprType(ctx, c.Factor)))
+	}
+	for i := len(n.Right) - 1; i >= 0; i-- {
+		pre, _, _ := opForm(ctx, n.Right[i].Operator, code.IsInteger(types[i+1]))
+		ctx.cw.Print(pre)
+		if truncates(types[i], types[i+1]) {
+			ctx.cw.Print("int(")
+		}
+	}
 	err := genMultiplication(ctx, n.Left)
 	if err != nil {
 		return err
 	}
-	for _, c := range n.Right {
-		err := genOpMultiplication(ctx, c)
+	for i, c := range n.Right {
+		if truncates(types[i], types[i+1]) {
+			ctx.cw.Print(")")
+		}
+		err := genOpMultiplication(ctx, c, types[i+1])
 		if err != nil {
 			return err
 		}
@@ -349,18 +534,71 @@ func genAddition(ctx *Context, n *ast.Addition) error {
 	return nil
 }
 
+// opForm returns the text emitted before the left operand of an operation,
+// in place of its operator and after its right operand. Integer division and
+// remainder truncate toward zero, as they do in scanspec, rather than floor.
+func opForm(ctx *Context, op ast.Operator, isint bool) (pre, mid, post string) {
+	switch {
+	case op == "/" && isint:
+		ctx.helpers["_div"] = true
+		return "_div(", ", ", ")"
+	case op == "%":
+		ctx.helpers["_mod"] = true
+		return "_mod(", ", ", ")"
+	case parenOperators[op]:
+		return "(", ASTOperator[op], ")"
+	}
+	return "", ASTOperator[op], ""
+}
+
 func genOpAddition(ctx *Context, n *ast.OpAddition) error {
-	ctx.cw.Print(string(n.Operator))
-	return genAddition(ctx, n.Addition)
+	ctx.cw.Print(ASTOperator[n.Operator])
+	err := genAddition(ctx, n.Addition)
+	if err != nil {
+		return err
+	}
+	if parenOperators[n.Operator] {
+		ctx.cw.Print(")")
+	}
+	return nil
 }
 
 func genMultiplication(ctx *Context, n *ast.Multiplication) error {
-	return genUnary(ctx, n.Unary)
+	if n.Exponent == nil {
+		return genUnary(ctx, n.Unary)
+	}
+	ctx.cw.Print("pow(")
+	err := genUnary(ctx, n.Unary)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(", ")
+	err = genPrimary(ctx, n.Exponent)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(")")
+	return nil
 }
 
-func genOpMultiplication(ctx *Context, n *ast.OpMultiplication) error {
-	ctx.cw.Print(string(n.Operator))
-	return genMultiplication(ctx, n.Factor)
+// genOpMultiplication emits the operator and right operand of n, an operation
+// on type t.
+func genOpMultiplication(ctx *Context, n *ast.OpMultiplication, t string) error {
+	_, mid, post := opForm(ctx, n.Operator, code.IsInteger(t))
+	ctx.cw.Print(mid)
+	conv := truncates(exprType(ctx, n.Factor), t)
+	if conv {
+		ctx.cw.Print("int(")
+	}
+	err := genMultiplication(ctx, n.Factor)
+	if err != nil {
+		return err
+	}
+	if conv {
+		ctx.cw.Print(")")
+	}
+	ctx.cw.Print(post)
+	return nil
 }
 
 func genUnary(ctx *Context, n *ast.Unary) error {
@@ -371,31 +609,125 @@ func genUnary(ctx *Context, n *ast.Unary) error {
 	case n.Negated != nil:
 		ctx.cw.Print("-")
 		return genPrimary(ctx, n.Negated)
+	case n.Not != nil:
+		ctx.cw.Print("(not ")
+		err := genPrimary(ctx, n.Not)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
 	}
 	panic("unreachable")
 }
 
 func genPrimary(ctx *Context, n *ast.Primary) error {
 	switch {
+	case n.Conversion != nil:
+		ctx.cw.Printf("%s(", ASTType[n.Conversion.Type])
+		err := genExpr(ctx, &n.Conversion.Expr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
+
+	case n.Quantifier != nil:
+		return genQuantifier(ctx, n.Quantifier)
+
 	case n.CallExpr != nil:
+		return genCall(ctx, n.CallExpr)
 
 	case n.Variable != nil:
-		ctx.cw.Print(n.Variable.Ident)
-		return nil
+		return genVariable(ctx, n.Variable)
 
 	case n.BasicLit != nil:
 		return genBasicLit(ctx, n.BasicLit)
 
 	case n.SubExpr != nil:
-		return genExpr(ctx, n.SubExpr)
+		ctx.cw.Print("(")
+		err := genExpr(ctx, n.SubExpr)
+		if err != nil {
+			return err
+		}
+		ctx.cw.Print(")")
+		return nil
 	}
 	panic("unreachable")
 }
 
+// genCall emits the call to a built-in function n as ASTFunction spells it.
+func genCall(ctx *Context, n *ast.CallExpr) error {
+	f, ok := ASTFunction[n.Ident]
+	if !ok {
+		return fmt.Errorf("py3: built-in function %s cannot be generated", n.Ident)
+	}
+	cw := ctx.cw
+	defer func() { ctx.cw = cw }()
+	args := make([]interface{}, len(n.Args))
+	for i := range n.Args {
+		ctx.cw = code.NewWriter("\t")
+		err := genOperand(ctx, &n.Args[i])
+		if err != nil {
+			return err
+		}
+		args[i] = string(ctx.cw.Bytes())
+	}
+	cw.Printf(f, args...)
+	return nil
+}
+
+// genQuantifier emits n as a call to all or any on a generator over the range
+// of n.
+func genQuantifier(ctx *Context, n *ast.Quantifier) error {
+	if n.Kind == "all" {
+		ctx.cw.Print("all(")
+	} else {
+		ctx.cw.Print("any(")
+	}
+	err := genExpr(ctx, &n.Expr)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Printf(" for %s in range(", n.Index)
+	err = genExpr(ctx, &n.Low)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print(", ")
+	err = genExpr(ctx, &n.High)
+	if err != nil {
+		return err
+	}
+	ctx.cw.Print("))")
+	return nil
+}
+
+// genVariable emits n with its indices, the last of which may be a slice.
+func genVariable(ctx *Context, n *ast.Variable) error {
+	ctx.cw.Print(n.Ident)
+	for i := range n.Indices {
+		ctx.cw.Print("[")
+		err := genExpr(ctx, &n.Indices[i])
+		if err != nil {
+			return err
+		}
+		if i == len(n.Indices)-1 && n.High != nil {
+			ctx.cw.Print(":")
+			err = genExpr(ctx, n.High)
+			if err != nil {
+				return err
+			}
+		}
+		ctx.cw.Print("]")
+	}
+	return nil
+}
+
 func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	switch {
 	case n.FloatLit != nil:
-		ctx.cw.Printf("%f", *n.FloatLit)
+		ctx.cw.Print(code.FormatFloat(*n.FloatLit))
 		return nil
 
 	case n.IntLit != nil:
@@ -405,6 +737,18 @@ func genBasicLit(ctx *Context, n *ast.BasicLit) error {
 	case n.StringLit != nil:
 		ctx.cw.Printf("%q", *n.StringLit)
 		return nil
+
+	case n.CharLit != nil:
+		ctx.cw.Printf("%q", string(rune(*n.CharLit)))
+		return nil
+
+	case n.BoolLit != nil:
+		if *n.BoolLit {
+			ctx.cw.Print("True")
+		} else {
+			ctx.cw.Print("False")
+		}
+		return nil
 	}
 
 	panic("unreachable")
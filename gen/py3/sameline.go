@@ -3,8 +3,6 @@
 package py3
 
 import (
-	"strings"
-
 	"git.furqansoftware.net/toph/scanlib/ast"
 )
 
@@ -13,28 +11,21 @@ type sameLine struct {
 }
 
 func (o sameLine) Generate(ctx *Context) error {
-	for _, f := range o.scanStmt.RefList {
-		ctx.cw.Printf("%s", f.Ident)
-		for _, i := range f.Indices {
-			ctx.cw.Print("[")
-			err := genExpr(ctx, &i)
-			if err != nil {
-				return err
-			}
-			ctx.cw.Print("]")
-		}
-		ctx.cw.Printf(" = %s(_.pop(0))", ctx.types[f.Ident+strings.Repeat("[]", len(f.Indices))])
-		ctx.cw.Println()
+	err := genSplit(ctx, o.scanStmt)
+	if err != nil {
+		return err
 	}
-	return nil
+	return genPops(ctx, o.scanStmt)
 }
 
 func (a *analyzer) sameLine(n *ast.Block) {
-	if a.blockEOLs[n] {
+	// The first iteration of a loop may start the line, so a loop body must
+	// read it if it has not been read yet.
+	if a.blockEOLs[n] || a.loopBlocks[n] {
 		return
 	}
 
-	ast.Inspect(n, func(n ast.Node) bool {
+	inspect(n, func(n ast.Node) bool {
 		switch n := n.(type) {
 		case *ast.Block, *ast.Statement:
 			return true
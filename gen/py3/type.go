@@ -1,19 +1,118 @@
 package py3
 
+import (
+	"strings"
+
+	"git.furqansoftware.net/toph/scanlib/ast"
+	"git.furqansoftware.net/toph/scanlib/gen/code"
+)
+
 var ASTType = map[string]string{
 	"bool":    "bool",
+	"char":    "str",
 	"int":     "int",
 	"int64":   "int",
+	"uint32":  "int",
+	"uint64":  "int",
+	"bigint":  "int",
 	"float32": "float",
 	"float64": "float",
+	"decimal": "float",
 	"string":  "string",
 }
 
 var ASTZero = map[string]string{
-	"bool":    "false",
+	"bool":    "False",
+	"char":    `""`,
 	"int":     "0",
 	"int64":   "0",
+	"uint32":  "0",
+	"uint64":  "0",
+	"bigint":  "0",
 	"float32": "0.0",
 	"float64": "0.0",
+	"decimal": "0.0",
 	"string":  `""`,
 }
+
+var ASTOperator = map[ast.Operator]string{
+	"+":   "+",
+	"-":   "-",
+	"|":   "|",
+	"xor": "^",
+	"*":   "*",
+	"/":   "/",
+	"%":   "%",
+	"<<":  "<<",
+	">>":  ">>",
+	"&":   "&",
+}
+
+// ASTFunction maps the built-in functions that can be generated to the format
+// of their calls, taking the arguments in order. Arguments that are called
+// methods on are parenthesized as needed.
+var ASTFunction = map[string]string{
+	"len":          "len(%s)",
+	"isLower":      `(set(%s) <= set("abcdefghijklmnopqrstuvwxyz"))`,
+	"isUpper":      `(set(%s) <= set("ABCDEFGHIJKLMNOPQRSTUVWXYZ"))`,
+	"isDigits":     `(set(%s) <= set("0123456789"))`,
+	"onlyChars":    "(set(%s) <= set(%s))",
+	"countChar":    "%s.count(%s)",
+	"contains":     "(%[2]s in %[1]s)",
+	"hasPrefix":    "%s.startswith(%s)",
+	"hasSuffix":    "%s.endswith(%s)",
+	"upper":        "%s.upper()",
+	"lower":        "%s.lower()",
+	"isPalindrome": "(%[1]s == %[1]s[::-1])",
+}
+
+// helperDefs holds the definitions of the functions that generated code
+// calls in place of Python operators that differ from their scanspec
+// counterparts. Integer division and remainder truncate toward zero, exactly
+// for integers of any size.
+var helperDefs = map[string]string{
+	"_div": "def _div(a, b):\n\tq = abs(a) // abs(b)\n\treturn q if (a < 0) == (b < 0) else -q\n",
+	"_mod": "def _mod(a, b):\n\tr = abs(a) % abs(b)\n\treturn r if a >= 0 else -r\n",
+}
+
+// parenOperators lists the operators that bind differently here than in
+// scanspec. Operations using them are parenthesized in full.
+var parenOperators = map[ast.Operator]bool{
+	"|":   true,
+	"xor": true,
+	"<<":  true,
+	">>":  true,
+	"&":   true,
+}
+
+// scanFunc returns the function that converts a token to the type of f. A row
+// of a char array is read as a list of characters.
+func scanFunc(ctx *Context, f *ast.Reference) string {
+	k := f.Ident + strings.Repeat("[]", len(f.Indices))
+	if ctx.types[k] == "array" && ctx.types[k+"[]"] == "str" {
+		return "list"
+	}
+	return ctx.types[k]
+}
+
+// exprType infers the scanspec type of n following the promotion rules of
+// package eval. Python tells fewer types apart: an int variable may hold any
+// integer type and a float one any floating-point type, which promote alike.
+func exprType(ctx *Context, n ast.Node) string {
+	return code.ExprType(n, func(v *ast.Variable) string {
+		switch t := ctx.types[v.Ident+strings.Repeat("[]", len(v.Indices))]; t {
+		case "float":
+			return "float64"
+		case "str":
+			return "char"
+		default:
+			return t
+		}
+	})
+}
+
+// truncates reports whether a value of type from is converted with int to be
+// used as type to, as a constant such as 2.0 is in an integer operation.
+func truncates(from, to string) bool {
+	return code.IsInteger(to) && !code.IsInteger(from)
+}
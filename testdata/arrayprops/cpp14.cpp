@@ -0,0 +1,22 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	int P[N];
+	long long int A[N];
+	double X[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> P[i];
+	}
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	for (int i = 0; i < N; ++i) {
+		cin >> X[i];
+	}
+	
+	return 0;
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var P [N]int
+	var A [N]int64
+	var X [N]float64
+	for i := 0; i < N; i++ {
+		fmt.Scan(&P[i])
+	}
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	for i := 0; i < N; i++ {
+		fmt.Scan(&X[i])
+	}
+	
+}
@@ -0,0 +1,17 @@
+_ = None
+N = int(input())
+P = [0] * N
+A = [0] * N
+X = [0.0] * N
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	P[i] = int(_.pop(0))
+_ = None
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	A[i] = int(_.pop(0))
+_ = None
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	X[i] = float(_.pop(0))
+_ = None
@@ -0,0 +1,28 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int cmp_bigint(const string &a, const string &b) {
+	bool neg = a[0] == '-';
+	if (neg != (b[0] == '-')) {
+		return neg ? -1 : 1;
+	}
+	int c = a.size() == b.size() ? a.compare(b) : a.size() < b.size() ? -1 : 1;
+	return neg ? -c : c;
+}
+
+int main() {
+	string N;
+	cin >> N;
+	int K;
+	cin >> K;
+	string A[K];
+	for (int i = 0; i < K; ++i) {
+		cin >> A[i];
+	}
+	if (cmp_bigint(A[0], "1000000000000000000000000000000")>0||cmp_bigint(to_string(K), N)<0) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+var _big0, _ = new(big.Int).SetString("1000000000000000000000000000000", 10)
+
+func main() {
+	var N = new(big.Int)
+	fmt.Scan(N)
+	var K int
+	fmt.Scan(&K)
+	var A [K]*big.Int
+	for _i := range A {
+		A[_i] = new(big.Int)
+	}
+	for i := 0; i < K; i++ {
+		fmt.Scan(A[i])
+	}
+	if A[0].Cmp(_big0)>0||big.NewInt(int64(K)).Cmp(N)<0 {
+	}
+	
+}
@@ -0,0 +1,5 @@
+N = int(input())
+K = int(input())
+A = list(map(int, input().split()))
+if A[0]>pow(10, 30) or K<N:
+	pass
@@ -0,0 +1,27 @@
+#include <cmath>
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int cmp_bigint(const string &a, const string &b) {
+	bool neg = a[0] == '-';
+	if (neg != (b[0] == '-')) {
+		return neg ? -1 : 1;
+	}
+	int c = a.size() == b.size() ? a.compare(b) : a.size() < b.size() ? -1 : 1;
+	return neg ? -c : c;
+}
+
+#define MAXV pow(10, 30)
+
+int main() {
+	string N, M;
+	cin >> N >> M;
+	long long int K;
+	cin >> K;
+	if (cmp_bigint(to_string(K), N)<0||cmp_bigint(M, "9223372036854775808")>=0) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const MAXV = 1000000000000000000000000000000
+var _big0, _ = new(big.Int).SetString("9223372036854775808", 10)
+
+func main() {
+	var N, M = new(big.Int), new(big.Int)
+	fmt.Scan(N, M)
+	var K int64
+	fmt.Scan(&K)
+	if big.NewInt(K).Cmp(N)<0||M.Cmp(_big0)>=0 {
+	}
+	
+}
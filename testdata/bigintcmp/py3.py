@@ -0,0 +1,5 @@
+MAXV = pow(10, 30)
+N, M = map(int, input().split())
+K = int(input())
+if K<N or M>=pow(2, 63):
+	pass
@@ -0,0 +1,16 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N, X;
+	cin >> N >> X;
+	int A[N/2+((1<<2))];
+	for (int i = 0; i < N%7; ++i) {
+		cin >> A[i];
+	}
+	if ((X&1)==0&&((N|X)^1)>8) {
+	}
+	
+	return 0;
+}
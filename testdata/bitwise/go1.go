@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N, X int
+	fmt.Scan(&N, &X)
+	var A [N/2+(1<<2)]int
+	for i := 0; i < N%7; i++ {
+		fmt.Scan(&A[i])
+	}
+	if X&1==0&&N|X^1>8 {
+	}
+	
+}
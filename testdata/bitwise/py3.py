@@ -0,0 +1,16 @@
+def _div(a, b):
+	q = abs(a) // abs(b)
+	return q if (a < 0) == (b < 0) else -q
+
+def _mod(a, b):
+	r = abs(a) % abs(b)
+	return r if a >= 0 else -r
+
+_ = None
+N, X = map(int, input().split())
+A = [0] * (_div(N, 2)+((1<<2)))
+for i in range(0, _mod(N, 7)):
+	if _ == None: _ = input().split()
+	A[i] = int(_.pop(0))
+if (X&1)==0 and ((N|X)^1)>pow(2, 3):
+	_ = None
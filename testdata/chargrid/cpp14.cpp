@@ -0,0 +1,16 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int R, C;
+	cin >> R >> C;
+	char G[R][C];
+	for (int i = 0; i < R; ++i) {
+		for (int _j = 0; _j < C; ++_j) cin >> G[i][_j];
+	}
+	char d;
+	cin >> d;
+	
+	return 0;
+}
@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+func main() {
+	var R, C int
+	fmt.Scan(&R, &C)
+	G := make([][]byte, R)
+	for _i0 := range G {
+		G[_i0] = make([]byte, C)
+	}
+	for i := 0; i < R; i++ {
+		{
+			var _t string
+			fmt.Scan(&_t)
+			copy(G[i], _t)
+		}
+	}
+	var d byte
+	{
+		var _t string
+		fmt.Scan(&_t)
+		d = _t[0]
+		_ = d
+	}
+	
+}
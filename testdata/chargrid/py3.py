@@ -0,0 +1,5 @@
+R, C = map(int, input().split())
+G = [[""] * C for _ in range(R)]
+for i in range(0, R):
+	G[i] = list(input())
+d = str(input())
@@ -0,0 +1,22 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	string T;
+	cin >> T;
+	if (T[0]=='g') {
+		int R, C;
+		cin >> R >> C;
+		string G[R];
+		for (int i = 0; i < R; ++i) {
+			cin >> G[i];
+		}
+	} else if (T.substr(0, 2-0)=="da") {
+		string D;
+		cin >> D;
+	}
+	
+	return 0;
+}
@@ -0,0 +1,20 @@
+package main
+
+import "fmt"
+
+func main() {
+	var T string
+	fmt.Scan(&T)
+	if T[0]=='g' {
+		var R, C int
+		fmt.Scan(&R, &C)
+		var G [R]string
+		for i := 0; i < R; i++ {
+			fmt.Scan(&G[i])
+		}
+	} else if T[0:2]=="da" {
+		var D string
+		fmt.Scan(&D)
+	}
+	
+}
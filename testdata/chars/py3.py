@@ -0,0 +1,8 @@
+T = input()
+if T[0]=="g":
+	R, C = map(int, input().split())
+	G = [""] * R
+	for i in range(0, R):
+		G[i] = input()
+elif T[0:2]=="da":
+	D = input()
@@ -0,0 +1,20 @@
+#include <iostream>
+
+using namespace std;
+
+#define MAXN 100
+#define MAXA 1000000000
+#define EPS 1e-06
+
+int main() {
+	int N;
+	cin >> N;
+	int A[N];
+	double X;
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	cin >> X;
+	
+	return 0;
+}
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+const MAXN = 100
+const MAXA = 1000000000
+const EPS = 1e-06
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var A [N]int
+	var X float64
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	fmt.Scan(&X)
+	
+}
@@ -0,0 +1,12 @@
+MAXN = 100
+MAXA = pow(10, 9)
+EPS = 1e-06
+_ = None
+N = int(input())
+A = [0] * N
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	A[i] = int(_.pop(0))
+if _ == None: _ = input().split()
+X = float(_.pop(0))
+_ = None
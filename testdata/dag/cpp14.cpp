@@ -0,0 +1,14 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N, M;
+	cin >> N >> M;
+	int U[M], V[M];
+	for (int i = 0; i < M; ++i) {
+		cin >> U[i] >> V[i];
+	}
+	
+	return 0;
+}
@@ -0,0 +1,16 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	double X[N], Y[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> X[i] >> Y[i];
+	}
+	double T;
+	cin >> T;
+	
+	return 0;
+}
@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var X, Y [N]float64
+	for i := 0; i < N; i++ {
+		fmt.Scan(&X[i], &Y[i])
+	}
+	var T float64
+	fmt.Scan(&T)
+	
+}
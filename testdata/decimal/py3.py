@@ -0,0 +1,10 @@
+_ = None
+N = int(input())
+X = [0.0] * N
+Y = [0.0] * N
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	X[i] = float(_.pop(0))
+	Y[i] = float(_.pop(0))
+	_ = None
+T = float(input())
@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N, M int
+	fmt.Scan(&N, &M)
+	var U, V [M]int
+	for i := 0; i < M; i++ {
+		fmt.Scan(&U[i], &V[i])
+	}
+	
+}
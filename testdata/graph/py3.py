@@ -0,0 +1,9 @@
+_ = None
+N, M = map(int, input().split())
+U = [0] * M
+V = [0] * M
+for i in range(0, M):
+	if _ == None: _ = input().split()
+	U[i] = int(_.pop(0))
+	V[i] = int(_.pop(0))
+	_ = None
@@ -0,0 +1,13 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	double F;
+	cin >> N >> F;
+	if (N/static_cast<int>(2.0)==3&&static_cast<int>(2.0)*N/4==3&&F/2>1) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	var F float64
+	fmt.Scan(&N, &F)
+	if N/2.0==3&&2.0*N/4==3&&F/2>1 {
+	}
+	
+}
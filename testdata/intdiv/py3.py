@@ -0,0 +1,11 @@
+def _div(a, b):
+	q = abs(a) // abs(b)
+	return q if (a < 0) == (b < 0) else -q
+
+_ = None
+if _ == None: _ = input().split()
+N = int(_.pop(0))
+F = float(_.pop(0))
+_ = None
+if _div(N, int(2.0))==3 and _div(int(2.0)*N, 4)==3 and F/2>1:
+	pass
@@ -0,0 +1,13 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int A, B;
+	cin >> A >> B;
+	string S;
+	cin >> S;
+	
+	return 0;
+}
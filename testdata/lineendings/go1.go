@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+func main() {
+	var A, B int
+	fmt.Scan(&A, &B)
+	var S string
+	fmt.Scan(&S)
+	
+}
@@ -0,0 +1,2 @@
+A, B = map(int, input().split())
+S = input()
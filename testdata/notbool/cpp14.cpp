@@ -0,0 +1,14 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	bool flag;
+	int A, B;
+	cin >> flag >> A >> B;
+	if (flag==true) {
+	} else if (!flag&&false==flag) {
+	}
+	
+	return 0;
+}
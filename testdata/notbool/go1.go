@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+func main() {
+	var flag bool
+	var A, B int
+	fmt.Scan(&flag, &A, &B)
+	if flag==true {
+	} else if !flag&&false==flag {
+	}
+	
+}
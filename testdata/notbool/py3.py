@@ -0,0 +1,10 @@
+_ = None
+if _ == None: _ = input().split()
+flag = bool(_.pop(0))
+A = int(_.pop(0))
+B = int(_.pop(0))
+_ = None
+if flag==True:
+	pass
+elif (not flag) and False==flag:
+	pass
@@ -0,0 +1,18 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	long long int M;
+	double P;
+	cin >> N >> M >> P;
+	int A[10];
+	for (int i = 0; i < 10; ++i) {
+		cin >> A[i];
+	}
+	if (P>0.25&&P<2.0) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	var M int64
+	var P float64
+	fmt.Scan(&N, &M, &P)
+	var A [10]int
+	for i := 0; i < 10; i++ {
+		fmt.Scan(&A[i])
+	}
+	if P>0.25&&P<2.0 {
+	}
+	
+}
@@ -0,0 +1,10 @@
+_ = None
+N, M, P = input().split()
+N, M, P = int(N), int(M), float(P)
+A = [0] * 10
+for i in range(0, 10):
+	if _ == None: _ = input().split()
+	A[i] = int(_.pop(0))
+_ = None
+if P>0.25 and P<2.0:
+	_ = None
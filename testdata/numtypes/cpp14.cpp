@@ -0,0 +1,20 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	long long int M;
+	double X;
+	cin >> N >> M >> X;
+	int A[static_cast<int>(X)+1];
+	int B[10];
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	cin >> B[0];
+	if (N+M>10&&static_cast<double>(N)<X&&M*2>N&&M<1099511627776) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	var M int64
+	var X float64
+	fmt.Scan(&N, &M, &X)
+	var A [int(X)+1]int
+	var B [10]int
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	fmt.Scan(&B[0])
+	if int64(N)+M>10&&float64(N)<X&&M*2>int64(N)&&M<1099511627776 {
+	}
+	
+}
@@ -0,0 +1,13 @@
+_ = None
+N, M, X = input().split()
+N, M, X = int(N), int(M), float(X)
+A = [0] * (int(X)+1)
+B = [0] * 10
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	A[i] = int(_.pop(0))
+if _ == None: _ = input().split()
+B[0] = int(_.pop(0))
+_ = None
+if N+M>10 and float(N)<X and M*2>N and M<pow(2, 40):
+	_ = None
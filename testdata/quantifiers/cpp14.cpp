@@ -0,0 +1,35 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int n, R, C;
+	cin >> n >> R >> C;
+	int A[n];
+	for (int i = 0; i < n; ++i) {
+		cin >> A[i];
+	}
+	string G[R];
+	for (int i = 0; i < R; ++i) {
+		cin >> G[i];
+	}
+	if ([&] {
+		for (int i = 0; i < n; ++i) {
+			if (A[i]>100) {
+				return true;
+			}
+		}
+		return false;
+	}()||[&] {
+		for (int i = 0; i < R; ++i) {
+			if (!(G[i][0]=='S')) {
+				return false;
+			}
+		}
+		return true;
+	}()) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,33 @@
+package main
+
+import "fmt"
+
+func main() {
+	var n, R, C int
+	fmt.Scan(&n, &R, &C)
+	var A [n]int
+	for i := 0; i < n; i++ {
+		fmt.Scan(&A[i])
+	}
+	var G [R]string
+	for i := 0; i < R; i++ {
+		fmt.Scan(&G[i])
+	}
+	if func() bool {
+		for i := 0; i < n; i++ {
+			if A[i]>100 {
+				return true
+			}
+		}
+		return false
+	}()||func() bool {
+		for i := 0; i < R; i++ {
+			if !(G[i][0]=='S') {
+				return false
+			}
+		}
+		return true
+	}() {
+	}
+	
+}
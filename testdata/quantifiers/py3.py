@@ -0,0 +1,7 @@
+n, R, C = map(int, input().split())
+A = list(map(int, input().split()))
+G = [""] * R
+for i in range(0, R):
+	G[i] = input()
+if any(A[i]>100 for i in range(0, n)) or all(G[i][0]=="S" for i in range(0, R)):
+	pass
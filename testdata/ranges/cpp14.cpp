@@ -0,0 +1,20 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int N;
+	double X;
+	cin >> N >> X;
+	int A[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	if (1<=N&&N<3||N>=10&&N<=20) {
+		string s;
+		cin >> s;
+	}
+	
+	return 0;
+}
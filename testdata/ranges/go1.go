@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	var X float64
+	fmt.Scan(&N, &X)
+	var A [N]int
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	if 1<=N&&N<3||N>=10&&N<=20 {
+		var s string
+		fmt.Scan(&s)
+	}
+	
+}
@@ -0,0 +1,8 @@
+_ = None
+if _ == None: _ = input().split()
+N = int(_.pop(0))
+X = float(_.pop(0))
+_ = None
+A = list(map(int, input().split()))
+if 1<=N<3 or 10<=N<=20:
+	s = input()
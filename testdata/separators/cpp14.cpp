@@ -0,0 +1,39 @@
+#include <iostream>
+#include <sstream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int H, M;
+	{
+		string _t;
+		cin >> _t;
+		istringstream _ss(_t);
+		string _f;
+		getline(_ss, _f, ':');
+		istringstream(_f) >> H;
+		getline(_ss, _f, ':');
+		istringstream(_f) >> M;
+	}
+	int N;
+	cin >> N;
+	int X[N], Y[N];
+	string S[N];
+	for (int i = 0; i < N; ++i) {
+		{
+			string _t;
+			cin >> _t;
+			istringstream _ss(_t);
+			string _f;
+			getline(_ss, _f, ',');
+			istringstream(_f) >> X[i];
+			getline(_ss, _f, ',');
+			istringstream(_f) >> Y[i];
+			getline(_ss, _f, ',');
+			istringstream(_f) >> S[i];
+		}
+	}
+	
+	return 0;
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	var H, M int
+	{
+		var _t string
+		fmt.Scan(&_t)
+		_f := strings.Split(_t, ":")
+		fmt.Sscan(_f[0], &H)
+		fmt.Sscan(_f[1], &M)
+	}
+	var N int
+	fmt.Scan(&N)
+	var X, Y [N]int
+	var S [N]string
+	for i := 0; i < N; i++ {
+		{
+			var _t string
+			fmt.Scan(&_t)
+			_f := strings.Split(_t, ",")
+			fmt.Sscan(_f[0], &X[i])
+			fmt.Sscan(_f[1], &Y[i])
+			fmt.Sscan(_f[2], &S[i])
+		}
+	}
+	
+}
@@ -0,0 +1,13 @@
+_ = None
+H, M = map(int, input().split(":"))
+N = int(input())
+X = [0] * N
+Y = [0] * N
+S = [""] * N
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	_[0:1] = _[0].split(",")
+	X[i] = int(_.pop(0))
+	Y[i] = int(_.pop(0))
+	S[i] = string(_.pop(0))
+	_ = None
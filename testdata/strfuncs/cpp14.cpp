@@ -0,0 +1,55 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int count_char(const string &s, char c) {
+	int n = 0;
+	for (char d : s) {
+		n += d == c;
+	}
+	return n;
+}
+
+bool has_prefix(const string &s, const string &t) {
+	return s.size() >= t.size() && s.compare(0, t.size(), t) == 0;
+}
+
+bool is_palindrome(const string &s) {
+	return string(s.rbegin(), s.rend()) == s;
+}
+
+string to_lower(string s) {
+	for (char &c : s) {
+		if ('A' <= c && c <= 'Z') {
+			c += 'a' - 'A';
+		}
+	}
+	return s;
+}
+
+string to_upper(string s) {
+	for (char &c : s) {
+		if ('a' <= c && c <= 'z') {
+			c -= 'a' - 'A';
+		}
+	}
+	return s;
+}
+
+int main() {
+	int N;
+	cin >> N;
+	for (int i = 0; i < N; ++i) {
+		string S, P;
+		cin >> S >> P;
+		if (is_palindrome(S)) {
+		}
+	}
+	string D;
+	cin >> D;
+	if ((D.find_first_not_of("abcdefghijklmnopqrstuvwxyz") == string::npos)||(to_upper(D).find_first_not_of("ABCDEFGHIJKLMNOPQRSTUVWXYZ") == string::npos)&&has_prefix(to_lower(D), "a")||(D.find_first_not_of("0123456789") == string::npos)&&count_char(D, '0')<D.size()&&!(D.find("99") != string::npos)) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func isPalindrome(s string) bool {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		if s[i] != s[j] {
+			return false
+		}
+	}
+	return true
+}
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	for i := 0; i < N; i++ {
+		var S, P string
+		fmt.Scan(&S, &P)
+		if isPalindrome(S) {
+		}
+	}
+	var D string
+	fmt.Scan(&D)
+	if (strings.Trim(D, "abcdefghijklmnopqrstuvwxyz") == "")||(strings.Trim(strings.ToUpper(D), "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "")&&strings.HasPrefix(strings.ToLower(D), "a")||(strings.Trim(D, "0123456789") == "")&&strings.Count(D, string('0'))<len(D)&&!strings.Contains(D, "99") {
+	}
+	
+}
@@ -0,0 +1,12 @@
+_ = None
+N = int(input())
+for i in range(0, N):
+	if _ == None: _ = input().split()
+	S = string(_.pop(0))
+	P = string(_.pop(0))
+	if (S == S[::-1]):
+		pass
+	_ = None
+D = input()
+if (set(D) <= set("abcdefghijklmnopqrstuvwxyz")) or (set(D.upper()) <= set("ABCDEFGHIJKLMNOPQRSTUVWXYZ")) and D.lower().startswith("a") or (set(D) <= set("0123456789")) and D.count("0")<len(D) and (not ("99" in D)):
+	pass
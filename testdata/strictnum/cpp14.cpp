@@ -0,0 +1,22 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	long long int A[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	double X;
+	cin >> X;
+	string C;
+	int K;
+	cin >> C >> K;
+	int M;
+	cin >> M;
+	
+	return 0;
+}
@@ -0,0 +1,20 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var A [N]int64
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	var X float64
+	fmt.Scan(&X)
+	var C string
+	var K int
+	fmt.Scan(&C, &K)
+	var M int
+	fmt.Scan(&M)
+	
+}
@@ -0,0 +1,9 @@
+_ = None
+N = int(input())
+A = list(map(int, input().split()))
+X = float(input())
+if _ == None: _ = input().split()
+C = string(_.pop(0))
+K = int(_.pop(0))
+_ = None
+M = int(input())
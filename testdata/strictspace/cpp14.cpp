@@ -0,0 +1,19 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	int A[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	string S;
+	cin >> S;
+	string T;
+	getline(cin, T);
+	
+	return 0;
+}
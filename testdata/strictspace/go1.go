@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var A [N]int
+	for i := 0; i < N; i++ {
+		fmt.Scan(&A[i])
+	}
+	var S string
+	fmt.Scan(&S)
+	var T string
+	fmt.Scanln(&T)
+	
+}
@@ -0,0 +1,5 @@
+N = int(input())
+A = list(map(int, input().split()))
+S = input()
+_ = None
+T = input()
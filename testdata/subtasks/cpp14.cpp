@@ -0,0 +1,14 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	int A[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> A[i];
+	}
+	
+	return 0;
+}
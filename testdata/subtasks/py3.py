@@ -0,0 +1,2 @@
+N = int(input())
+A = list(map(int, input().split()))
@@ -0,0 +1,17 @@
+#include <iostream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int T;
+	cin >> T;
+	for (int i = 0; i < T; ++i) {
+		int n;
+		string s;
+		cin >> n;
+		cin >> s;
+	}
+	
+	return 0;
+}
@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func main() {
+	var T int
+	fmt.Scan(&T)
+	for i := 0; i < T; i++ {
+		var n int
+		var s string
+		fmt.Scan(&n)
+		fmt.Scan(&s)
+	}
+	
+}
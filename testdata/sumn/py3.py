@@ -0,0 +1,4 @@
+T = int(input())
+for i in range(0, T):
+	n = int(input())
+	s = input()
@@ -2,7 +2,7 @@ _ = None
 T = int(input())
 for i in range(0, T):
 	n, q = map(int, input().split())
-	A = map(int, input().split())
+	A = list(map(int, input().split()))
 	for j in range(0, q):
 		if _ == None: _ = input().split()
 		c = int(_.pop(0))
@@ -1,4 +1,4 @@
 t = int(input())
 for i in range(0, t):
 	n = int(input())
-	a = map(int, input().split())
+	a = list(map(int, input().split()))
@@ -0,0 +1,14 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	int U[N-1], V[N-1];
+	for (int i = 0; i < N-1; ++i) {
+		cin >> U[i] >> V[i];
+	}
+	
+	return 0;
+}
@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var U, V [N-1]int
+	for i := 0; i < N-1; i++ {
+		fmt.Scan(&U[i], &V[i])
+	}
+	
+}
@@ -0,0 +1,9 @@
+_ = None
+N = int(input())
+U = [0] * (N-1)
+V = [0] * (N-1)
+for i in range(0, N-1):
+	if _ == None: _ = input().split()
+	U[i] = int(_.pop(0))
+	V[i] = int(_.pop(0))
+	_ = None
@@ -0,0 +1,18 @@
+#include <iostream>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	unsigned long long int H[N];
+	for (int i = 0; i < N; ++i) {
+		cin >> H[i];
+	}
+	unsigned int M;
+	cin >> M;
+	if (M<2147483648&&H[0]>=9223372036854775808ULL&&H[0]%4294967296!=M) {
+	}
+	
+	return 0;
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var H [N]uint64
+	for i := 0; i < N; i++ {
+		fmt.Scan(&H[i])
+	}
+	var M uint32
+	fmt.Scan(&M)
+	if M<2147483648&&H[0]>=9223372036854775808&&H[0]%4294967296!=uint64(M) {
+	}
+	
+}
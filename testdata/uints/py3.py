@@ -0,0 +1,9 @@
+def _mod(a, b):
+	r = abs(a) % abs(b)
+	return r if a >= 0 else -r
+
+N = int(input())
+H = list(map(int, input().split()))
+M = int(input())
+if M<pow(2, 31) and H[0]>=pow(2, 63) and _mod(H[0], pow(2, 32))!=M:
+	pass
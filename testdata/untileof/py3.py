@@ -3,7 +3,7 @@ while True:
 	try:
 		if _ == None: _ = input().split()
 		cmd = string(_.pop(0))
-		if cmd=="PUSH"||cmd=="REPEAT":
+		if cmd=="PUSH" or cmd=="REPEAT":
 			param = int(_.pop(0))
 		if cmd=="REPEAT":
 			pass
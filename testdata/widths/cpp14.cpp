@@ -0,0 +1,33 @@
+#include <iostream>
+#include <sstream>
+#include <string>
+
+using namespace std;
+
+int main() {
+	int N;
+	cin >> N;
+	int D[N];
+	{
+		string _t;
+		cin >> _t;
+		for (int _k = 0; _k < N; ++_k) {
+			istringstream(_t.substr(0, 1)) >> D[_k];
+			_t.erase(0, 1);
+		}
+	}
+	string L;
+	int Y, P;
+	{
+		string _t;
+		cin >> _t;
+		istringstream(_t.substr(0, 2)) >> L;
+		_t.erase(0, 2);
+		istringstream(_t.substr(0, 2)) >> Y;
+		_t.erase(0, 2);
+		istringstream(_t.substr(0, 2)) >> P;
+		_t.erase(0, 2);
+	}
+	
+	return 0;
+}
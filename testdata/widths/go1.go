@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+func main() {
+	var N int
+	fmt.Scan(&N)
+	var D [N]int
+	{
+		var _t string
+		fmt.Scan(&_t)
+		for _k := 0; _k < N; _k++ {
+			fmt.Sscan(_t[:1], &D[_k])
+			_t = _t[1:]
+		}
+	}
+	var L string
+	var Y, P int
+	{
+		var _t string
+		fmt.Scan(&_t)
+		fmt.Sscan(_t[:2], &L)
+		_t = _t[2:]
+		fmt.Sscan(_t[:2], &Y)
+		_t = _t[2:]
+		fmt.Sscan(_t[:2], &P)
+		_t = _t[2:]
+	}
+	
+}
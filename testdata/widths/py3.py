@@ -0,0 +1,14 @@
+_ = None
+N = int(input())
+D = [0] * N
+if _ == None: _ = input().split()
+_[0:1] = [_[0][_i:_i + 1] for _i in range(0, len(_[0]), 1)]
+for _k in range(0, N):
+	D[_k] = int(_.pop(0))
+_ = None
+if _ == None: _ = input().split()
+_[0:1] = [_[0][_i:_i + 2] for _i in range(0, len(_[0]), 2)]
+L = string(_.pop(0))
+Y = int(_.pop(0))
+P = int(_.pop(0))
+_ = None